@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Arquivo responde a partir de um arquivo JSON local com uma lista de
+// Endereco, carregado uma única vez na primeira consulta
+type Arquivo struct {
+	Path string
+
+	once      sync.Once
+	enderecos map[CEP]Endereco
+	err       error
+}
+
+func (a *Arquivo) Name() string {
+	return "arquivo"
+}
+
+func (a *Arquivo) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	a.once.Do(a.carregar)
+	if a.err != nil {
+		return Endereco{}, fmt.Errorf("%w: %v", ErrUpstreamUnavailable, a.err)
+	}
+
+	endereco, ok := a.enderecos[cep]
+	if !ok {
+		return Endereco{}, ErrCEPNotFound
+	}
+	return endereco, nil
+}
+
+func (a *Arquivo) carregar() {
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		a.err = err
+		return
+	}
+
+	var enderecos []Endereco
+	if err := json.Unmarshal(data, &enderecos); err != nil {
+		a.err = err
+		return
+	}
+
+	a.enderecos = make(map[CEP]Endereco, len(enderecos))
+	for _, endereco := range enderecos {
+		cep, err := ParseCEP(endereco.Cep)
+		if err != nil {
+			continue
+		}
+		a.enderecos[cep] = endereco
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// minAutocompletar é o tamanho mínimo de cidade e logradouro, o mesmo exigido pela ViaCEP
+	minAutocompletar = 3
+	limitePadrao     = 10
+	limiteMaximo     = 50
+)
+
+// ErrBuscaInvalida indica parâmetros de autocompletar fora das regras
+var ErrBuscaInvalida = errors.New("busca inválida")
+
+// Autocompletador busca endereços pela UF, cidade e parte do logradouro
+type Autocompletador interface {
+	Autocompletar(ctx context.Context, uf, cidade, logradouro string) ([]Endereco, error)
+}
+
+// autocompletador é a fonte usada pelo autocompletarHandler, configurada em main
+var autocompletador Autocompletador = ViaCEP{}
+
+// Autocompletar usa a busca /ws/{UF}/{cidade}/{logradouro}/json/ da ViaCEP,
+// que devolve até 50 endereços
+func (v ViaCEP) Autocompletar(ctx context.Context, uf, cidade, logradouro string) ([]Endereco, error) {
+	baseURL := v.BaseURL
+	if baseURL == "" {
+		baseURL = "https://viacep.com.br/ws/"
+	}
+	endpoint := baseURL + url.PathEscape(uf) + "/" + url.PathEscape(cidade) + "/" + url.PathEscape(logradouro) + "/json/"
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := httpClient(v.Client).Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUpstreamUnavailable, err)
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest:
+		return nil, ErrBuscaInvalida
+	default:
+		return nil, fmt.Errorf("%w: status %d", ErrUpstreamUnavailable, response.StatusCode)
+	}
+
+	var enderecos []Endereco
+	if err := json.NewDecoder(response.Body).Decode(&enderecos); err != nil {
+		return nil, fmt.Errorf("%w: resposta inválida: %v", ErrUpstreamUnavailable, err)
+	}
+	return enderecos, nil
+}
+
+// Autocompletar busca no índice local os logradouros que começam com logradouro
+func (o *Offline) Autocompletar(ctx context.Context, uf, cidade, logradouro string) ([]Endereco, error) {
+	return o.BuscarPorLogradouro(uf, cidade, logradouro, limiteMaximo), nil
+}
+
+// AutocompletarCache guarda as buscas de outro Autocompletador por um TTL,
+// descartando as menos usadas acima de MaxEntries
+type AutocompletarCache struct {
+	Autocompletador Autocompletador
+	TTL             time.Duration
+	MaxEntries      int
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type buscaEmCache struct {
+	chave     string
+	enderecos []Endereco
+	expiresAt time.Time
+}
+
+func (c *AutocompletarCache) Autocompletar(ctx context.Context, uf, cidade, logradouro string) ([]Endereco, error) {
+	chave := chaveCidade(uf, cidade) + "/" + normalizar(logradouro)
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.lru = list.New()
+		c.entries = map[string]*list.Element{}
+	}
+	if element, ok := c.entries[chave]; ok {
+		busca := element.Value.(buscaEmCache)
+		if time.Now().Before(busca.expiresAt) {
+			c.lru.MoveToFront(element)
+			c.mu.Unlock()
+			return busca.enderecos, nil
+		}
+		c.lru.Remove(element)
+		delete(c.entries, chave)
+	}
+	c.mu.Unlock()
+
+	enderecos, err := c.Autocompletador.Autocompletar(ctx, uf, cidade, logradouro)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[chave]; !ok {
+		c.entries[chave] = c.lru.PushFront(buscaEmCache{chave: chave, enderecos: enderecos, expiresAt: time.Now().Add(c.TTL)})
+		if c.MaxEntries > 0 && c.lru.Len() > c.MaxEntries {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(buscaEmCache).chave)
+		}
+	}
+	return enderecos, nil
+}
+
+// autocompletarHandler responde GET /autocompletar?uf=SP&cidade=São Paulo&logradouro=Paulis&limite=10
+func autocompletarHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	uf := strings.ToUpper(strings.TrimSpace(query.Get("uf")))
+	cidade := strings.TrimSpace(query.Get("cidade"))
+	logradouro := strings.TrimSpace(query.Get("logradouro"))
+
+	if len(uf) != 2 {
+		writeError(w, http.StatusBadRequest, "invalid_query", "Parâmetro 'uf' deve ter 2 letras")
+		return
+	}
+	if len([]rune(cidade)) < minAutocompletar || len([]rune(logradouro)) < minAutocompletar {
+		writeError(w, http.StatusBadRequest, "invalid_query",
+			fmt.Sprintf("Parâmetros 'cidade' e 'logradouro' devem ter pelo menos %d caracteres", minAutocompletar))
+		return
+	}
+
+	limite := limitePadrao
+	if param := query.Get("limite"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 1 || n > limiteMaximo {
+			writeError(w, http.StatusBadRequest, "invalid_query",
+				fmt.Sprintf("Parâmetro 'limite' deve estar entre 1 e %d", limiteMaximo))
+			return
+		}
+		limite = n
+	}
+
+	enderecos, err := autocompletador.Autocompletar(r.Context(), uf, cidade, logradouro)
+	if errors.Is(err, ErrBuscaInvalida) {
+		writeError(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	}
+	if err != nil {
+		status, code := errorStatus(err)
+		writeError(w, status, code, "Erro ao buscar endereços: "+err.Error())
+		return
+	}
+
+	if len(enderecos) > limite {
+		enderecos = enderecos[:limite]
+	}
+	if enderecos == nil {
+		enderecos = []Endereco{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enderecos)
+}
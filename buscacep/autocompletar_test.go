@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAutocompletarHandler(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.EscapedPath() != "/ws/SP/S%C3%A3o%20Paulo/Paulis/json/" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+		w.Write([]byte(`[
+			{"cep": "01310-100", "logradouro": "Avenida Paulista"},
+			{"cep": "01311-000", "logradouro": "Avenida Paulista"},
+			{"cep": "01311-100", "logradouro": "Avenida Paulista"}
+		]`))
+	}))
+	defer server.Close()
+
+	anterior := autocompletador
+	autocompletador = &AutocompletarCache{Autocompletador: ViaCEP{BaseURL: server.URL + "/ws/"}, TTL: time.Minute}
+	t.Cleanup(func() { autocompletador = anterior })
+
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/autocompletar?uf=sp&cidade=S%C3%A3o+Paulo&logradouro=Paulis&limite=2", nil)
+		autocompletarHandler(recorder, request)
+
+		var enderecos []Endereco
+		json.NewDecoder(recorder.Body).Decode(&enderecos)
+		if recorder.Code != http.StatusOK || len(enderecos) != 2 {
+			t.Errorf("expected 2 addresses, got %d %+v", recorder.Code, enderecos)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected second search to be cached, got %d calls", calls.Load())
+	}
+}
+
+func TestAutocompletarHandlerValidation(t *testing.T) {
+	cases := []string{
+		"/autocompletar?uf=SPX&cidade=Santos&logradouro=Rua",
+		"/autocompletar?uf=SP&cidade=Sa&logradouro=Rua",
+		"/autocompletar?uf=SP&cidade=Santos&logradouro=Ru",
+		"/autocompletar?uf=SP&cidade=Santos&logradouro=Rua&limite=500",
+	}
+
+	for _, target := range cases {
+		recorder := httptest.NewRecorder()
+		autocompletarHandler(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: expected 400, got %d", target, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var (
+	// batchWorkers é o número de consultas simultâneas de um lote
+	batchWorkers = 8
+	// batchMaxCEPs é o tamanho máximo de um lote
+	batchMaxCEPs = 10000
+	// batchMaxBytes limita o corpo do lote antes de decodificá-lo
+	batchMaxBytes int64 = 1 << 20
+)
+
+// batchResult é o resultado de um CEP do lote
+type batchResult struct {
+	Index    int            `json:"index"`
+	Cep      string         `json:"cep"`
+	Endereco *Endereco      `json:"endereco,omitempty"`
+	Error    *errorResponse `json:"error,omitempty"`
+}
+
+// batchHandler recebe um POST com um array JSON ou um CSV de CEPs e devolve
+// o resultado de cada um, na ordem de entrada. Com "Accept: application/x-ndjson"
+// os resultados são enviados um por linha, assim que ficam prontos.
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Use POST para consultar um lote de CEPs")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, batchMaxBytes)
+	ceps, err := lerLote(r)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "batch_too_large",
+			fmt.Sprintf("O corpo do lote passa de %d bytes", tooLarge.Limit))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_batch", err.Error())
+		return
+	}
+	if len(ceps) > batchMaxCEPs {
+		writeError(w, http.StatusRequestEntityTooLarge, "batch_too_large",
+			fmt.Sprintf("O lote tem %d CEPs, o máximo é %d", len(ceps), batchMaxCEPs))
+		return
+	}
+
+	resultados := consultarLote(r, ceps)
+
+	if strings.Contains(r.Header.Get("Accept"), "application/x-ndjson") {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher, _ := w.(http.Flusher)
+		encoder := json.NewEncoder(w)
+		for resultado := range resultados {
+			encoder.Encode(resultado)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return
+	}
+
+	ordenados := make([]batchResult, len(ceps))
+	for resultado := range resultados {
+		ordenados[resultado.Index] = resultado
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ordenados)
+}
+
+// lerLote lê os CEPs do corpo como array JSON ou, com Content-Type text/csv,
+// da primeira coluna de cada linha, ignorando um cabeçalho
+func lerLote(r *http.Request) ([]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType == "text/csv" {
+		reader := csv.NewReader(r.Body)
+		reader.FieldsPerRecord = -1
+
+		var ceps []string
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("CSV inválido: %w", err)
+			}
+			if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+				continue
+			}
+			if len(ceps) == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "cep") {
+				continue
+			}
+			ceps = append(ceps, record[0])
+		}
+		return ceps, nil
+	}
+
+	var ceps []string
+	if err := json.NewDecoder(r.Body).Decode(&ceps); err != nil {
+		return nil, fmt.Errorf("esperado um array JSON de CEPs: %w", err)
+	}
+	return ceps, nil
+}
+
+// consultarLote busca os CEPs com no máximo batchWorkers consultas simultâneas
+// e envia cada resultado no canal assim que fica pronto
+func consultarLote(r *http.Request, ceps []string) <-chan batchResult {
+	indices := make(chan int)
+	resultados := make(chan batchResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < batchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				resultados <- consultarItem(r, index, ceps[index])
+			}
+		}()
+	}
+
+	go func() {
+		defer close(indices)
+		for index := range ceps {
+			select {
+			case indices <- index:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(resultados)
+	}()
+
+	return resultados
+}
+
+func consultarItem(r *http.Request, index int, entrada string) batchResult {
+	resultado := batchResult{Index: index, Cep: entrada}
+
+	cep, err := ParseCEP(entrada)
+	if err == nil {
+		resultado.Cep = cep.String()
+		var endereco Endereco
+		endereco, err = buscarCep(r.Context(), cep)
+		if err == nil {
+			resultado.Endereco = &endereco
+			return resultado
+		}
+	}
+
+	_, code := errorStatus(err)
+	resultado.Error = &errorResponse{Error: code, Message: err.Error()}
+	return resultado
+}
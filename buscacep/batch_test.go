@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBatchHandlerJSON(t *testing.T) {
+	usarProvedor(t, &contador{})
+
+	request := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`["01001-000", "x", "20040020"]`))
+	recorder := httptest.NewRecorder()
+	batchHandler(recorder, request)
+
+	var resultados []batchResult
+	if err := json.NewDecoder(recorder.Body).Decode(&resultados); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resultados) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(resultados))
+	}
+	if resultados[0].Endereco == nil || resultados[0].Cep != "01001-000" {
+		t.Errorf("expected first CEP to resolve, got %+v", resultados[0])
+	}
+	if resultados[1].Error == nil || resultados[1].Error.Error != "invalid_cep" {
+		t.Errorf("expected invalid_cep for second item, got %+v", resultados[1])
+	}
+	if resultados[2].Index != 2 || resultados[2].Cep != "20040-020" {
+		t.Errorf("expected results in input order, got %+v", resultados[2])
+	}
+}
+
+func TestBatchHandlerCSVStreaming(t *testing.T) {
+	usarProvedor(t, &contador{})
+
+	request := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader("cep,nome\n01001000,a\n20040020,b\n"))
+	request.Header.Set("Content-Type", "text/csv")
+	request.Header.Set("Accept", "application/x-ndjson")
+	recorder := httptest.NewRecorder()
+	batchHandler(recorder, request)
+
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expected NDJSON, got %q", ct)
+	}
+
+	linhas := 0
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		var resultado batchResult
+		if err := json.Unmarshal(scanner.Bytes(), &resultado); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		if resultado.Endereco == nil {
+			t.Errorf("expected address, got %+v", resultado)
+		}
+		linhas++
+	}
+	if linhas != 2 {
+		t.Errorf("expected 2 lines, got %d", linhas)
+	}
+}
+
+func TestBatchHandlerBodyTooLarge(t *testing.T) {
+	usarProvedor(t, &contador{})
+
+	corpo := `["` + strings.Repeat("0", int(batchMaxBytes)) + `"]`
+	recorder := httptest.NewRecorder()
+	batchHandler(recorder, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(corpo)))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", recorder.Code)
+	}
+}
+
+func TestBatchHandlerRejectsGet(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	batchHandler(recorder, httptest.NewRequest(http.MethodGet, "/batch", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// BrasilAPI consulta https://brasilapi.com.br/api/cep/v1/{cep}
+type BrasilAPI struct {
+	// BaseURL permite apontar para outro servidor, como um httptest.Server
+	BaseURL string
+	Client  *http.Client
+}
+
+func (b BrasilAPI) Name() string {
+	return "brasilapi"
+}
+
+func (b BrasilAPI) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	baseURL := b.BaseURL
+	if baseURL == "" {
+		baseURL = "https://brasilapi.com.br/api/cep/v1/"
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+cep.Digits(), nil)
+	if err != nil {
+		return Endereco{}, err
+	}
+
+	response, err := httpClient(b.Client).Do(request)
+	if err != nil {
+		return Endereco{}, fmt.Errorf("%w: %v", ErrUpstreamUnavailable, err)
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return Endereco{}, ErrCEPNotFound
+	case http.StatusBadRequest:
+		return Endereco{}, ErrInvalidCEP
+	default:
+		return Endereco{}, fmt.Errorf("%w: status %d", ErrUpstreamUnavailable, response.StatusCode)
+	}
+
+	var resposta struct {
+		Cep          string `json:"cep"`
+		State        string `json:"state"`
+		City         string `json:"city"`
+		Neighborhood string `json:"neighborhood"`
+		Street       string `json:"street"`
+	}
+
+	err = json.NewDecoder(response.Body).Decode(&resposta)
+	if err != nil {
+		return Endereco{}, fmt.Errorf("%w: resposta inválida: %v", ErrUpstreamUnavailable, err)
+	}
+
+	return Endereco{
+		Cep:        cep.String(),
+		Logradouro: resposta.Street,
+		Bairro:     resposta.Neighborhood,
+		Localidade: resposta.City,
+		Uf:         resposta.State,
+	}, nil
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// CacheConfig define o comportamento do Cache
+type CacheConfig struct {
+	// TTL é quanto tempo um endereço encontrado fica no cache
+	TTL time.Duration
+	// NegativeTTL é quanto tempo um CEP não encontrado fica no cache; zero desliga o cache negativo
+	NegativeTTL time.Duration
+	// MaxEntries limita o número de CEPs guardados; os menos usados saem primeiro
+	MaxEntries int
+	// Store, se definido, guarda o cache entre reinícios do servidor
+	Store CacheStore
+	// Timeout limita a consulta ao provedor. A consulta é compartilhada, então
+	// não é cancelada quando quem a iniciou desiste; padrão de 10s
+	Timeout time.Duration
+}
+
+// CacheEntry é um CEP guardado no cache
+type CacheEntry struct {
+	Cep       CEP       `json:"cep"`
+	Endereco  Endereco  `json:"endereco"`
+	NotFound  bool      `json:"not_found"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CacheStore persiste as entradas do cache
+type CacheStore interface {
+	Load() ([]CacheEntry, error)
+	Save(entries []CacheEntry) error
+}
+
+// Cache é um Provider que guarda as respostas de outro Provider em memória,
+// com TTL, limite LRU, cache negativo e uma única consulta por CEP em andamento
+type Cache struct {
+	provider Provider
+	config   CacheConfig
+
+	mu      sync.Mutex
+	lru     *list.List // frente = usado mais recentemente
+	entries map[CEP]*list.Element
+	calls   map[CEP]*cacheCall
+
+	// persistMu impede duas gravações simultâneas no mesmo arquivo temporário
+	persistMu sync.Mutex
+}
+
+// cacheCall é uma consulta em andamento, compartilhada por quem pedir o mesmo CEP
+type cacheCall struct {
+	done     chan struct{}
+	endereco Endereco
+	err      error
+}
+
+// NewCache cria um Cache na frente de provider e carrega as entradas
+// ainda válidas do Store, se houver
+func NewCache(provider Provider, config CacheConfig) *Cache {
+	c := &Cache{
+		provider: provider,
+		config:   config,
+		lru:      list.New(),
+		entries:  map[CEP]*list.Element{},
+		calls:    map[CEP]*cacheCall{},
+	}
+
+	if config.Store != nil {
+		entries, err := config.Store.Load()
+		if err != nil {
+			log.Printf("Cache: erro ao carregar entradas persistidas: %v", err)
+		}
+		now := time.Now()
+		for _, entry := range entries {
+			if entry.ExpiresAt.After(now) {
+				c.add(entry)
+			}
+		}
+	}
+	return c
+}
+
+func (c *Cache) Name() string {
+	return "cache(" + c.provider.Name() + ")"
+}
+
+func (c *Cache) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	c.mu.Lock()
+	if entry, ok := c.get(cep); ok {
+		c.mu.Unlock()
+		if entry.NotFound {
+			return Endereco{}, ErrCEPNotFound
+		}
+		return entry.Endereco, nil
+	}
+
+	// Uma única consulta por CEP; quem chegar depois espera pela mesma
+	call, ok := c.calls[cep]
+	if !ok {
+		call = &cacheCall{done: make(chan struct{})}
+		c.calls[cep] = call
+		go c.consultar(ctx, cep, call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.endereco, call.err
+	case <-ctx.Done():
+		return Endereco{}, ctx.Err()
+	}
+}
+
+// consultar faz a consulta compartilhada e guarda o resultado. Ela usa os
+// valores de ctx mas não o seu cancelamento: se o cliente que a iniciou
+// desconectar, os outros que esperam pelo mesmo CEP ainda recebem o endereço.
+func (c *Cache) consultar(ctx context.Context, cep CEP, call *cacheCall) {
+	timeout := c.config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	call.endereco, call.err = c.provider.BuscarCep(ctx, cep)
+
+	c.mu.Lock()
+	delete(c.calls, cep)
+	switch {
+	case call.err == nil && c.config.TTL > 0:
+		c.add(CacheEntry{Cep: cep, Endereco: call.endereco, ExpiresAt: time.Now().Add(c.config.TTL)})
+	case errors.Is(call.err, ErrCEPNotFound) && c.config.NegativeTTL > 0:
+		c.add(CacheEntry{Cep: cep, NotFound: true, ExpiresAt: time.Now().Add(c.config.NegativeTTL)})
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// get devolve a entrada do CEP se ainda for válida. Deve ser chamado com c.mu travado.
+func (c *Cache) get(cep CEP) (CacheEntry, bool) {
+	element, ok := c.entries[cep]
+	if !ok {
+		return CacheEntry{}, false
+	}
+
+	entry := element.Value.(CacheEntry)
+	if time.Now().After(entry.ExpiresAt) {
+		c.lru.Remove(element)
+		delete(c.entries, cep)
+		return CacheEntry{}, false
+	}
+
+	c.lru.MoveToFront(element)
+	return entry, true
+}
+
+// add guarda a entrada, descartando a menos usada se o limite for atingido.
+// Deve ser chamado com c.mu travado.
+func (c *Cache) add(entry CacheEntry) {
+	if element, ok := c.entries[entry.Cep]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[entry.Cep] = c.lru.PushFront(entry)
+	if c.config.MaxEntries > 0 && c.lru.Len() > c.config.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(CacheEntry).Cep)
+	}
+}
+
+// Len devolve quantos CEPs estão no cache, incluindo os já expirados
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Persist grava as entradas válidas no Store
+func (c *Cache) Persist() error {
+	if c.config.Store == nil {
+		return nil
+	}
+	c.persistMu.Lock()
+	defer c.persistMu.Unlock()
+
+	c.mu.Lock()
+	now := time.Now()
+	entries := make([]CacheEntry, 0, c.lru.Len())
+	// Do menos para o mais usado, para que Load reconstrua a mesma ordem LRU
+	for element := c.lru.Back(); element != nil; element = element.Prev() {
+		if entry := element.Value.(CacheEntry); entry.ExpiresAt.After(now) {
+			entries = append(entries, entry)
+		}
+	}
+	c.mu.Unlock()
+
+	return c.config.Store.Save(entries)
+}
+
+// PersistEvery chama Persist a cada intervalo até ctx ser cancelado
+func (c *Cache) PersistEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Persist(); err != nil {
+				log.Printf("Cache: erro ao persistir: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// FileStore persiste o cache em um arquivo JSON
+type FileStore struct {
+	Path string
+}
+
+func (f FileStore) Load() ([]CacheEntry, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []CacheEntry
+	err = json.Unmarshal(data, &entries)
+	return entries, err
+}
+
+// Save grava em um arquivo temporário e renomeia, para nunca deixar um
+// arquivo pela metade se o processo morrer durante a escrita
+func (f FileStore) Save(entries []CacheEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Path)
+}
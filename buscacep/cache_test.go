@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// contador é um Provider falso que conta as consultas recebidas
+type contador struct {
+	calls atomic.Int32
+	delay time.Duration
+	err   error
+}
+
+func (c *contador) Name() string {
+	return "contador"
+}
+
+func (c *contador) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	c.calls.Add(1)
+	time.Sleep(c.delay)
+	if c.err != nil {
+		return Endereco{}, c.err
+	}
+	return Endereco{Cep: cep.String()}, nil
+}
+
+func TestCacheHitAndExpiry(t *testing.T) {
+	provider := &contador{}
+	cache := NewCache(provider, CacheConfig{TTL: 50 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		cache.BuscarCep(context.Background(), "01001000")
+	}
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Errorf("expected 1 upstream call, got %d", calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	cache.BuscarCep(context.Background(), "01001000")
+	if calls := provider.calls.Load(); calls != 2 {
+		t.Errorf("expected expired entry to be fetched again, got %d calls", calls)
+	}
+}
+
+func TestCacheNegative(t *testing.T) {
+	provider := &contador{err: ErrCEPNotFound}
+	cache := NewCache(provider, CacheConfig{TTL: time.Minute, NegativeTTL: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.BuscarCep(context.Background(), "99999999"); !errors.Is(err, ErrCEPNotFound) {
+			t.Errorf("expected ErrCEPNotFound, got %v", err)
+		}
+	}
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Errorf("expected not found to be cached, got %d calls", calls)
+	}
+
+	provider.err = ErrUpstreamUnavailable
+	cache.BuscarCep(context.Background(), "11111111")
+	cache.BuscarCep(context.Background(), "11111111")
+	if calls := provider.calls.Load(); calls != 3 {
+		t.Errorf("expected upstream failures not to be cached, got %d calls", calls)
+	}
+}
+
+func TestCacheLRU(t *testing.T) {
+	cache := NewCache(&contador{}, CacheConfig{TTL: time.Minute, MaxEntries: 2})
+
+	cache.BuscarCep(context.Background(), "00000001")
+	cache.BuscarCep(context.Background(), "00000002")
+	cache.BuscarCep(context.Background(), "00000001") // 00000002 passa a ser o menos usado
+	cache.BuscarCep(context.Background(), "00000003")
+
+	if cache.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", cache.Len())
+	}
+	if _, ok := cache.get("00000002"); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if _, ok := cache.get("00000001"); !ok {
+		t.Error("expected recently used entry to stay")
+	}
+}
+
+func TestCacheSingleflight(t *testing.T) {
+	provider := &contador{delay: 50 * time.Millisecond}
+	cache := NewCache(provider, CacheConfig{TTL: time.Minute})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.BuscarCep(context.Background(), "01001000")
+		}()
+	}
+	wg.Wait()
+
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Errorf("expected concurrent lookups to share one call, got %d", calls)
+	}
+}
+
+// espera é um Provider falso que só responde quando liberado, ou falha
+// quando o contexto é cancelado
+type espera struct {
+	liberar chan struct{}
+}
+
+func (e *espera) Name() string {
+	return "espera"
+}
+
+func (e *espera) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	select {
+	case <-e.liberar:
+		return Endereco{Cep: cep.String()}, nil
+	case <-ctx.Done():
+		return Endereco{}, ctx.Err()
+	}
+}
+
+func TestCacheLeaderCancelled(t *testing.T) {
+	provider := &espera{liberar: make(chan struct{})}
+	cache := NewCache(provider, CacheConfig{TTL: time.Minute})
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := cache.BuscarCep(leaderCtx, "01001000")
+		leader <- err
+	}()
+	// Esperar a consulta do líder estar em andamento
+	for {
+		cache.mu.Lock()
+		started := len(cache.calls) == 1
+		cache.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	follower := make(chan error, 1)
+	go func() {
+		endereco, err := cache.BuscarCep(context.Background(), "01001000")
+		if err == nil && endereco.Cep != "01001-000" {
+			err = errors.New("unexpected address " + endereco.Cep)
+		}
+		follower <- err
+	}()
+
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader: expected context.Canceled, got %v", err)
+	}
+	close(provider.liberar)
+	if err := <-follower; err != nil {
+		t.Errorf("follower: expected the address, got %v", err)
+	}
+}
+
+func TestCachePersistence(t *testing.T) {
+	store := FileStore{Path: filepath.Join(t.TempDir(), "cache.json")}
+
+	cache := NewCache(&contador{}, CacheConfig{TTL: time.Minute, Store: store})
+	cache.BuscarCep(context.Background(), "01001000")
+	if err := cache.Persist(); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+
+	provider := &contador{}
+	restarted := NewCache(provider, CacheConfig{TTL: time.Minute, Store: store})
+	endereco, err := restarted.BuscarCep(context.Background(), "01001000")
+	if err != nil || endereco.Cep != "01001-000" {
+		t.Errorf("expected persisted entry, got %+v, %v", endereco, err)
+	}
+	if calls := provider.calls.Load(); calls != 0 {
+		t.Errorf("expected no upstream call after restart, got %d", calls)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "strings"
+
+// CEP guarda apenas os 8 dígitos, sem máscara
+type CEP string
+
+// ParseCEP remove espaços e a máscara ("-" e ".") e valida os 8 dígitos,
+// então "01234-567", " 01234567 " e "01.234-567" viram o mesmo CEP
+func ParseCEP(s string) (CEP, error) {
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '-' || r == '.' || r == ' ' || r == '\t':
+			// máscara ou espaço, ignorar
+		default:
+			return "", ErrInvalidCEP
+		}
+	}
+
+	if digits.Len() != 8 {
+		return "", ErrInvalidCEP
+	}
+	return CEP(digits.String()), nil
+}
+
+// Digits retorna o CEP sem máscara, como a ViaCEP espera na URL
+func (c CEP) Digits() string {
+	return string(c)
+}
+
+// String formata o CEP como "00000-000"
+func (c CEP) String() string {
+	if len(c) != 8 {
+		return string(c)
+	}
+	return string(c[:5]) + "-" + string(c[5:])
+}
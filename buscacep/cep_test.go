@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestParseCEP(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "01234567", expected: "01234-567"},
+		{input: "01234-567", expected: "01234-567"},
+		{input: "01234567 ", expected: "01234-567"},
+		{input: " 01.234-567", expected: "01234-567"},
+	}
+
+	for _, c := range cases {
+		cep, err := ParseCEP(c.input)
+		if err != nil {
+			t.Errorf("ParseCEP(%q) returned error: %v", c.input, err)
+			continue
+		}
+		if cep.String() != c.expected {
+			t.Errorf("ParseCEP(%q) = %q, expected %q", c.input, cep.String(), c.expected)
+		}
+	}
+}
+
+func TestParseCEPInvalid(t *testing.T) {
+	invalid := []string{"", "1234567", "012345678", "../x", "0123456a", "01234-567/../"}
+
+	for _, input := range invalid {
+		if _, err := ParseCEP(input); err != ErrInvalidCEP {
+			t.Errorf("ParseCEP(%q) expected ErrInvalidCEP, got %v", input, err)
+		}
+	}
+}
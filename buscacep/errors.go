@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrInvalidCEP indica um CEP que não tem exatamente 8 dígitos
+	ErrInvalidCEP = errors.New("CEP inválido: informe 8 dígitos, com ou sem máscara (00000-000)")
+	// ErrCEPNotFound indica um CEP válido que não existe na base consultada
+	ErrCEPNotFound = errors.New("CEP não encontrado")
+	// ErrUpstreamUnavailable indica falha de rede ou resposta inesperada do serviço de CEP
+	ErrUpstreamUnavailable = errors.New("serviço de CEP indisponível")
+)
+
+// errorStatus traduz os erros de busca em status HTTP e código do envelope
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrInvalidCEP):
+		return http.StatusBadRequest, "invalid_cep"
+	case errors.Is(err, ErrCEPNotFound):
+		return http.StatusNotFound, "cep_not_found"
+	case errors.Is(err, ErrUpstreamUnavailable):
+		return http.StatusBadGateway, "upstream_unavailable"
+	case errors.Is(err, ErrSemCoordenadas):
+		return http.StatusUnprocessableEntity, "coordinates_unavailable"
+	default:
+		return http.StatusInternalServerError, "internal_error"
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrSemCoordenadas indica um endereço cujo município não está na base do IBGE carregada
+var ErrSemCoordenadas = errors.New("coordenadas do município não disponíveis")
+
+// Coordenadas de um município, em graus
+type Coordenadas struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Municipios é a base de municípios do IBGE, indexada pelo código e, quando
+// o CSV traz o nome e a UF, também pelo nome, para os provedores que não
+// informam o código, como a BrasilAPI
+type Municipios struct {
+	coordenadas map[string]Coordenadas // por código IBGE
+	codigos     map[string]string      // código IBGE por chaveMunicipio
+}
+
+// Len é o número de municípios com coordenadas
+func (m Municipios) Len() int {
+	return len(m.coordenadas)
+}
+
+// Localizar devolve o código IBGE e as coordenadas do município do endereço,
+// pelo código IBGE ou, na falta dele, pela cidade e UF
+func (m Municipios) Localizar(endereco Endereco) (string, Coordenadas, bool) {
+	codigo := endereco.Ibge
+	if codigo == "" {
+		codigo = m.codigos[chaveMunicipio(endereco.Uf, endereco.Localidade)]
+	}
+	coordenadas, ok := m.coordenadas[codigo]
+	return codigo, coordenadas, ok
+}
+
+// chaveMunicipio identifica um município pela UF e pelo nome sem acentos,
+// ex.: "SP/sao paulo"
+func chaveMunicipio(uf, nome string) string {
+	return strings.ToUpper(strings.TrimSpace(uf)) + "/" + normalizar(nome)
+}
+
+// ufPorCodigo converte o código de UF do IBGE na sigla
+var ufPorCodigo = map[string]string{
+	"11": "RO", "12": "AC", "13": "AM", "14": "RR", "15": "PA", "16": "AP", "17": "TO",
+	"21": "MA", "22": "PI", "23": "CE", "24": "RN", "25": "PB", "26": "PE", "27": "AL", "28": "SE", "29": "BA",
+	"31": "MG", "32": "ES", "33": "RJ", "35": "SP",
+	"41": "PR", "42": "SC", "43": "RS",
+	"50": "MS", "51": "MT", "52": "GO", "53": "DF",
+}
+
+// CarregarMunicipios lê um CSV de municípios do IBGE com cabeçalho contendo
+// codigo_ibge, latitude e longitude. Com as colunas nome e uf (sigla) ou
+// codigo_uf, os municípios também podem ser localizados pelo nome; as demais
+// colunas são ignoradas.
+func CarregarMunicipios(r io.Reader) (Municipios, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	cabecalho, err := reader.Read()
+	if err != nil {
+		return Municipios{}, fmt.Errorf("lendo cabeçalho: %w", err)
+	}
+	colunas := map[string]int{}
+	for i, nome := range cabecalho {
+		colunas[normalizar(nome)] = i
+	}
+	codigo, okCodigo := colunas["codigo_ibge"]
+	lat, okLat := colunas["latitude"]
+	lon, okLon := colunas["longitude"]
+	if !okCodigo || !okLat || !okLon {
+		return Municipios{}, errors.New("o cabeçalho precisa de codigo_ibge, latitude e longitude")
+	}
+	nome, okNome := colunas["nome"]
+	uf, okUF := colunas["uf"]
+	codigoUF, okCodigoUF := colunas["codigo_uf"]
+
+	municipios := Municipios{coordenadas: map[string]Coordenadas{}, codigos: map[string]string{}}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Municipios{}, err
+		}
+		if len(record) <= max(codigo, lat, lon) {
+			continue
+		}
+
+		latitude, errLat := strconv.ParseFloat(strings.TrimSpace(record[lat]), 64)
+		longitude, errLon := strconv.ParseFloat(strings.TrimSpace(record[lon]), 64)
+		if errLat != nil || errLon != nil {
+			continue
+		}
+		codigoIBGE := strings.TrimSpace(record[codigo])
+		municipios.coordenadas[codigoIBGE] = Coordenadas{Latitude: latitude, Longitude: longitude}
+
+		sigla := ""
+		switch {
+		case okUF && uf < len(record):
+			sigla = record[uf]
+		case okCodigoUF && codigoUF < len(record):
+			sigla = ufPorCodigo[strings.TrimSpace(record[codigoUF])]
+		}
+		if okNome && nome < len(record) && strings.TrimSpace(sigla) != "" {
+			municipios.codigos[chaveMunicipio(sigla, record[nome])] = codigoIBGE
+		}
+	}
+	return municipios, nil
+}
+
+// Enriquecedor é um Provider que acrescenta as coordenadas do município aos
+// endereços de outro Provider, preenchendo também o código IBGE quando o
+// município foi encontrado pelo nome
+type Enriquecedor struct {
+	Provider   Provider
+	Municipios Municipios
+}
+
+func (e Enriquecedor) Name() string {
+	return e.Provider.Name()
+}
+
+func (e Enriquecedor) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	endereco, err := e.Provider.BuscarCep(ctx, cep)
+	if err != nil {
+		return endereco, err
+	}
+	if codigo, coordenadas, ok := e.Municipios.Localizar(endereco); ok {
+		endereco.Ibge = codigo
+		endereco.Coordenadas = &coordenadas
+	}
+	return endereco, nil
+}
+
+// ZonaFrete classifica a distância entre origem e destino para a cotação de frete
+type ZonaFrete string
+
+const (
+	ZonaLocal    ZonaFrete = "local"    // mesmo município
+	ZonaEstadual ZonaFrete = "estadual" // mesma UF
+	ZonaRegional ZonaFrete = "regional" // outra UF, até 500 km
+	ZonaNacional ZonaFrete = "nacional" // outra UF, mais de 500 km
+)
+
+// Distancia entre dois CEPs, em linha reta
+type Distancia struct {
+	Origem      Endereco  `json:"origem"`
+	Destino     Endereco  `json:"destino"`
+	DistanciaKm float64   `json:"distancia_km"`
+	Zona        ZonaFrete `json:"zona"`
+}
+
+// CalcularDistancia busca os dois CEPs e calcula a distância em linha reta
+// entre seus municípios e a zona de frete
+func CalcularDistancia(ctx context.Context, origem, destino CEP) (Distancia, error) {
+	enderecoOrigem, err := buscarCep(ctx, origem)
+	if err != nil {
+		return Distancia{}, fmt.Errorf("origem: %w", err)
+	}
+	enderecoDestino, err := buscarCep(ctx, destino)
+	if err != nil {
+		return Distancia{}, fmt.Errorf("destino: %w", err)
+	}
+	if enderecoOrigem.Coordenadas == nil || enderecoDestino.Coordenadas == nil {
+		return Distancia{}, ErrSemCoordenadas
+	}
+
+	km := haversineKm(*enderecoOrigem.Coordenadas, *enderecoDestino.Coordenadas)
+	return Distancia{
+		Origem:      enderecoOrigem,
+		Destino:     enderecoDestino,
+		DistanciaKm: math.Round(km*10) / 10,
+		Zona:        zonaFrete(enderecoOrigem, enderecoDestino, km),
+	}, nil
+}
+
+func zonaFrete(origem, destino Endereco, km float64) ZonaFrete {
+	switch {
+	case origem.Ibge != "" && origem.Ibge == destino.Ibge:
+		return ZonaLocal
+	case strings.EqualFold(origem.Uf, destino.Uf):
+		return ZonaEstadual
+	case km <= 500:
+		return ZonaRegional
+	default:
+		return ZonaNacional
+	}
+}
+
+// haversineKm é a distância sobre a superfície da Terra, considerada uma esfera
+func haversineKm(a, b Coordenadas) float64 {
+	const raioTerraKm = 6371.0
+	rad := func(graus float64) float64 { return graus * math.Pi / 180 }
+
+	dLat := rad(b.Latitude - a.Latitude)
+	dLon := rad(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(a.Latitude))*math.Cos(rad(b.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * raioTerraKm * math.Asin(math.Sqrt(h))
+}
+
+// distanciaHandler responde GET /distancia?origem=01001000&destino=20040020
+func distanciaHandler(w http.ResponseWriter, r *http.Request) {
+	var ceps [2]CEP
+	for i, param := range []string{"origem", "destino"} {
+		cep, err := ParseCEP(r.URL.Query().Get(param))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_cep", fmt.Sprintf("Parâmetro '%s': %v", param, err))
+			return
+		}
+		ceps[i] = cep
+	}
+
+	distancia, err := CalcularDistancia(r.Context(), ceps[0], ceps[1])
+	if err != nil {
+		status, code := errorStatus(err)
+		writeError(w, status, code, "Erro ao calcular distância: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(distancia)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// enderecosFixos é um Provider falso com endereços conhecidos
+type enderecosFixos map[CEP]Endereco
+
+func (e enderecosFixos) Name() string {
+	return "fixos"
+}
+
+func (e enderecosFixos) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	endereco, ok := e[cep]
+	if !ok {
+		return Endereco{}, ErrCEPNotFound
+	}
+	return endereco, nil
+}
+
+func TestCalcularDistancia(t *testing.T) {
+	file, err := os.Open("testdata/municipios.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	municipios, err := CarregarMunicipios(file)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	usarProvedor(t, Enriquecedor{Municipios: municipios, Provider: enderecosFixos{
+		"01001000": {Cep: "01001-000", Uf: "SP", Ibge: "3550308"},
+		"01310100": {Cep: "01310-100", Uf: "SP", Ibge: "3550308"},
+		"13010000": {Cep: "13010-000", Uf: "SP", Ibge: "3509502"},
+		"20040020": {Cep: "20040-020", Uf: "RJ", Ibge: "3304557"},
+		"90010000": {Cep: "90010-000", Uf: "RS", Ibge: "4314902"},
+		"69005000": {Cep: "69005-000", Uf: "AM", Ibge: "1302603"},
+	}})
+
+	cases := []struct {
+		origem, destino CEP
+		zona            ZonaFrete
+		km              float64
+	}{
+		{origem: "01001000", destino: "01310100", zona: ZonaLocal, km: 0},
+		{origem: "01001000", destino: "13010000", zona: ZonaEstadual, km: 84},
+		{origem: "01001000", destino: "20040020", zona: ZonaRegional, km: 357},
+		{origem: "20040020", destino: "90010000", zona: ZonaNacional, km: 1124},
+	}
+
+	for _, c := range cases {
+		distancia, err := CalcularDistancia(context.Background(), c.origem, c.destino)
+		if err != nil {
+			t.Errorf("%s -> %s: %v", c.origem, c.destino, err)
+			continue
+		}
+		if distancia.Zona != c.zona {
+			t.Errorf("%s -> %s: expected zone %s, got %s", c.origem, c.destino, c.zona, distancia.Zona)
+		}
+		if math.Abs(distancia.DistanciaKm-c.km) > 5 {
+			t.Errorf("%s -> %s: expected about %.0f km, got %.1f", c.origem, c.destino, c.km, distancia.DistanciaKm)
+		}
+	}
+
+	if _, err := CalcularDistancia(context.Background(), "01001000", "69005000"); !errors.Is(err, ErrSemCoordenadas) {
+		t.Errorf("expected ErrSemCoordenadas for unknown municipality, got %v", err)
+	}
+}
+
+func TestDistanciaViaBrasilAPI(t *testing.T) {
+	file, err := os.Open("testdata/municipios.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	municipios, err := CarregarMunicipios(file)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// A BrasilAPI não informa o código IBGE, só a cidade e a UF
+	respostas := map[string]string{
+		"/01001000": `{"cep": "01001000", "state": "SP", "city": "São Paulo", "street": "Praça da Sé"}`,
+		"/20040020": `{"cep": "20040020", "state": "RJ", "city": "Rio de Janeiro"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resposta, ok := respostas[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resposta))
+	}))
+	defer server.Close()
+	usarProvedor(t, Enriquecedor{Municipios: municipios, Provider: BrasilAPI{BaseURL: server.URL + "/"}})
+
+	recorder := httptest.NewRecorder()
+	distanciaHandler(recorder, httptest.NewRequest(http.MethodGet, "/distancia?origem=01001000&destino=20040020", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var distancia Distancia
+	if err := json.NewDecoder(recorder.Body).Decode(&distancia); err != nil {
+		t.Fatal(err)
+	}
+	if distancia.Origem.Ibge != "3550308" || distancia.Destino.Ibge != "3304557" {
+		t.Errorf("expected IBGE codes found by name, got %q and %q", distancia.Origem.Ibge, distancia.Destino.Ibge)
+	}
+	if distancia.Zona != ZonaRegional || math.Abs(distancia.DistanciaKm-357) > 5 {
+		t.Errorf("expected about 357 km in the regional zone, got %.1f %s", distancia.DistanciaKm, distancia.Zona)
+	}
+}
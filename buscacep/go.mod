@@ -0,0 +1,12 @@
+module github.com/guilhermehermes/curso-go/buscacep
+
+go 1.24.4
+
+require (
+	github.com/guilhermehermes/curso-go/httpclient v0.0.0
+	github.com/guilhermehermes/curso-go/observability v0.0.0
+)
+
+replace github.com/guilhermehermes/curso-go/httpclient => ../httpclient
+
+replace github.com/guilhermehermes/curso-go/observability => ../observability
@@ -1,13 +1,93 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"time"
+
+	"github.com/guilhermehermes/curso-go/observability"
 )
 
-func listenAndServeTLS() {
+// tlsOpcoes configura o servidor HTTPS
+type tlsOpcoes struct {
+	Addr     string // endereço HTTPS, ex.: ":8443"
+	HTTPAddr string // endereço HTTP que redireciona para HTTPS; vazio desliga
+	CertFile string // vazio gera um certificado autoassinado
+	KeyFile  string
+	// ReloadInterval é de quanto em quanto tempo os arquivos de certificado são verificados
+	ReloadInterval time.Duration
+	// Observer expõe /healthz, /readyz e /metrics; nil desliga
+	Observer *observability.Observer
+}
+
+func listenAndServeTLS(ctx context.Context, opcoes tlsOpcoes) error {
 	http.HandleFunc("/", buscaCepHandler)
-	http.ListenAndServe(":8080", nil)
+	http.HandleFunc("/batch", batchHandler)
+	http.HandleFunc("/distancia", distanciaHandler)
+	http.HandleFunc("/autocompletar", autocompletarHandler)
+
+	var handler http.Handler = http.DefaultServeMux
+	if opcoes.Observer != nil {
+		opcoes.Observer.Mount(http.DefaultServeMux)
+		handler = opcoes.Observer.Middleware(handler)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// h2 primeiro para que os clientes negociem HTTP/2
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+
+	if opcoes.CertFile == "" {
+		log.Println("TLS: sem -cert/-key, usando certificado autoassinado para localhost")
+		cert, err := gerarCertificadoAutoassinado()
+		if err != nil {
+			return err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	} else {
+		reloader, err := newCertReloader(opcoes.CertFile, opcoes.KeyFile)
+		if err != nil {
+			return err
+		}
+		go reloader.watch(opcoes.ReloadInterval)
+		tlsConfig.GetCertificate = reloader.GetCertificate
+	}
+
+	if opcoes.HTTPAddr != "" {
+		go func() {
+			log.Printf("Redirecionando HTTP em %s para HTTPS", opcoes.HTTPAddr)
+			err := http.ListenAndServe(opcoes.HTTPAddr, redirectToHTTPS(opcoes.Addr))
+			log.Printf("Servidor de redirecionamento parou: %v", err)
+		}()
+	}
+
+	server := &http.Server{
+		Addr:      opcoes.Addr,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+
+	// Quando ctx termina, parar de aceitar conexões e esperar as requisições
+	// em andamento por até 10s
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Erro ao encerrar o servidor: %v", err)
+		}
+	}()
+
+	err := server.ListenAndServeTLS("", "")
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
 func buscaCepHandler(w http.ResponseWriter, r *http.Request) {
@@ -18,18 +98,35 @@ func buscaCepHandler(w http.ResponseWriter, r *http.Request) {
 
 	cepParam := r.URL.Query().Get("cep")
 	if cepParam == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("Parâmetro 'cep' é obrigatório"))
+		writeError(w, http.StatusBadRequest, "missing_cep", "Parâmetro 'cep' é obrigatório")
+		return
+	}
+
+	cep, err := ParseCEP(cepParam)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_cep", err.Error())
 		return
 	}
 
-	endereco, err := buscarCep(cepParam)
+	endereco, err := buscarCep(r.Context(), cep)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Erro ao buscar CEP: " + err.Error()))
+		status, code := errorStatus(err)
+		writeError(w, status, code, "Erro ao buscar CEP: "+err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(endereco)
 }
+
+// errorResponse é o corpo JSON devolvido em caso de erro
+type errorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: code, Message: message})
+}
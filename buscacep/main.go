@@ -1,9 +1,18 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/guilhermehermes/curso-go/httpclient"
+	"github.com/guilhermehermes/curso-go/observability"
 )
 
 type Endereco struct {
@@ -20,32 +29,202 @@ type Endereco struct {
 	Gia         string `json:"gia"`
 	Ddd         string `json:"ddd"`
 	Siafi       string `json:"siafi"`
+	// Coordenadas do município, preenchidas quando há uma base do IBGE carregada
+	Coordenadas *Coordenadas `json:"coordenadas,omitempty"`
 }
 
-func buscarCep(cep string) (Endereco, error) {
-	url := "https://viacep.com.br/ws/" + cep + "/json/"
+// provedor é a fonte usada pelo buscaCepHandler, configurada em main
+var provedor Provider = ViaCEP{}
 
-	response, err := http.Get(url)
-	if err != nil {
-		return Endereco{}, err
+func buscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	return provedor.BuscarCep(ctx, cep)
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "importar" {
+		importar(os.Args[2:])
+		return
 	}
-	defer response.Body.Close()
 
-	if response.StatusCode != http.StatusOK {
-		return Endereco{}, err
+	estrategia := flag.String("estrategia", "viacep", "viacep, offline, race (todos em paralelo) ou failover (um após o outro)")
+	timeout := flag.Duration("timeout", 3*time.Second, "tempo máximo de cada provedor nas estratégias race e failover")
+	arquivo := flag.String("arquivo", "", "arquivo JSON com endereços locais, usado como provedor adicional")
+	municipios := flag.String("municipios", "", "CSV de municípios do IBGE (codigo_ibge, latitude, longitude e, para a BrasilAPI, nome e codigo_uf) para coordenadas e distâncias")
+	offline := flag.String("offline", "", "índice gerado por 'importar', usado como primeiro provedor")
+	cacheTTL := flag.Duration("cache-ttl", 24*time.Hour, "tempo que um endereço fica em cache; 0 desliga o cache")
+	cacheNegativeTTL := flag.Duration("cache-negative-ttl", 10*time.Minute, "tempo que um CEP não encontrado fica em cache")
+	cacheMax := flag.Int("cache-max", 10000, "número máximo de CEPs em cache")
+	cacheArquivo := flag.String("cache-arquivo", "", "arquivo onde o cache é persistido entre reinícios")
+	addr := flag.String("addr", ":8443", "endereço do servidor HTTPS")
+	httpAddr := flag.String("http-addr", ":8080", "endereço HTTP que redireciona para HTTPS; vazio desliga")
+	certFile := flag.String("cert", "", "certificado TLS (PEM); sem ele é gerado um autoassinado")
+	keyFile := flag.String("key", "", "chave privada do certificado TLS (PEM)")
+	flag.IntVar(&batchWorkers, "batch-workers", batchWorkers, "consultas simultâneas em um POST /batch")
+	flag.Parse()
+
+	if batchWorkers < 1 {
+		log.Fatal("-batch-workers deve ser pelo menos 1")
+	}
+
+	viaCEP := ViaCEP{Client: novoCliente("viacep")}
+	providers := []Provider{viaCEP, BrasilAPI{Client: novoCliente("brasilapi")}}
+	if *arquivo != "" {
+		providers = append([]Provider{&Arquivo{Path: *arquivo}}, providers...)
 	}
+	if *offline != "" {
+		indice, err := AbrirOffline(*offline)
+		if err != nil {
+			log.Fatalf("Erro ao abrir índice offline: %v", err)
+		}
+		log.Printf("Índice offline com %d CEPs carregado", indice.Len())
+		providers = append([]Provider{indice}, providers...)
+	}
+
+	// /readyz verifica se os serviços externos usados pela estratégia respondem;
+	// o índice offline já foi carregado acima e não precisa de verificação
+	obs := observability.New()
+	verificador := &http.Client{Timeout: 2 * time.Second}
+	checkViaCEP := observability.HTTPCheck(verificador, "https://viacep.com.br/")
+	checkBrasilAPI := observability.HTTPCheck(verificador, "https://brasilapi.com.br/")
 
-	var endereco Endereco
+	autocompletador = viaCEP
+	switch *estrategia {
+	case "viacep":
+		provedor = viaCEP
+		obs.AddCheck("viacep", checkViaCEP)
+	case "offline":
+		if *offline == "" {
+			log.Fatal("A estratégia offline precisa do parâmetro -offline")
+		}
+		provedor = providers[0]
+		autocompletador = providers[0].(*Offline)
+	case "race":
+		provedor = Race{Providers: providers, Timeout: *timeout}
+		obs.AddCheck("provedores", algumDisponivel(checkViaCEP, checkBrasilAPI))
+	case "failover":
+		provedor = Failover{Providers: providers, Timeout: *timeout}
+		obs.AddCheck("provedores", algumDisponivel(checkViaCEP, checkBrasilAPI))
+	default:
+		log.Fatalf("Estratégia desconhecida: %s", *estrategia)
+	}
+
+	if *municipios != "" {
+		file, err := os.Open(*municipios)
+		if err != nil {
+			log.Fatalf("Erro ao abrir municípios: %v", err)
+		}
+		base, err := CarregarMunicipios(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("Erro ao carregar municípios: %v", err)
+		}
+		log.Printf("Coordenadas de %d municípios carregadas", base.Len())
+		provedor = Enriquecedor{Provider: provedor, Municipios: base}
+	}
+
+	// SIGINT e SIGTERM encerram o servidor e a gravação periódica do cache
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var cache *Cache
+	if *cacheTTL > 0 {
+		config := CacheConfig{TTL: *cacheTTL, NegativeTTL: *cacheNegativeTTL, MaxEntries: *cacheMax}
+		if *cacheArquivo != "" {
+			config.Store = FileStore{Path: *cacheArquivo}
+		}
+		cache = NewCache(provedor, config)
+		go cache.PersistEvery(ctx, time.Minute)
+		provedor = cache
+
+		autocompletador = &AutocompletarCache{Autocompletador: autocompletador, TTL: *cacheTTL, MaxEntries: 1000}
+	}
+
+	if (*certFile == "") != (*keyFile == "") {
+		log.Fatal("Informe -cert e -key juntos")
+	}
 
-	err = json.NewDecoder(response.Body).Decode(&endereco)
+	log.Printf("Iniciando servidor HTTPS em %s (estratégia %s)...", *addr, *estrategia)
+	err := listenAndServeTLS(ctx, tlsOpcoes{
+		Addr:           *addr,
+		HTTPAddr:       *httpAddr,
+		CertFile:       *certFile,
+		KeyFile:        *keyFile,
+		ReloadInterval: 30 * time.Second,
+		Observer:       obs,
+	})
 	if err != nil {
-		return Endereco{}, err
+		log.Fatal(err)
 	}
 
-	return endereco, nil
+	// Gravar o cache uma última vez para não perder o último minuto
+	if cache != nil {
+		if err := cache.Persist(); err != nil {
+			log.Printf("Cache: erro ao persistir: %v", err)
+		}
+	}
+	log.Println("Servidor encerrado")
 }
 
-func main() {
-	log.Println("Iniciando servidor na porta 8080...")
-	listenAndServeTLS()
+// algumDisponivel passa quando ao menos uma das verificações passa: nas
+// estratégias race e failover basta um provedor respondendo
+func algumDisponivel(checks ...observability.Check) observability.Check {
+	return func(ctx context.Context) error {
+		var erros []error
+		for _, check := range checks {
+			err := check(ctx)
+			if err == nil {
+				return nil
+			}
+			erros = append(erros, err)
+		}
+		return errors.Join(erros...)
+	}
+}
+
+// novoCliente cria o cliente HTTP de um provedor, com seu próprio circuit
+// breaker para que a falha de um serviço não bloqueie os outros
+func novoCliente(name string) *http.Client {
+	return httpclient.New(httpclient.Config{
+		Timeout:          10 * time.Second,
+		AttemptTimeout:   3 * time.Second,
+		MaxRetries:       2,
+		BaseDelay:        200 * time.Millisecond,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+		Hooks:            httpclient.LogHooks(log.Default(), name),
+	})
+}
+
+// importar implementa "buscacep importar -entrada ceps.csv -saida ceps.idx"
+func importar(args []string) {
+	flags := flag.NewFlagSet("importar", flag.ExitOnError)
+	entrada := flags.String("entrada", "", "CSV com cabeçalho cep, logradouro, complemento, bairro, cidade, uf, ibge")
+	saida := flags.String("saida", "ceps.idx", "arquivo do índice gerado")
+	separador := flags.String("separador", ",", "separador de colunas do CSV (a exportação do DNE usa @)")
+	flags.Parse(args)
+
+	if *entrada == "" || len([]rune(*separador)) != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	in, err := os.Open(*entrada)
+	if err != nil {
+		log.Fatalf("Erro ao abrir %s: %v", *entrada, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(*saida)
+	if err != nil {
+		log.Fatalf("Erro ao criar %s: %v", *saida, err)
+	}
+
+	total, err := ImportarCSV(in, []rune(*separador)[0], out)
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		log.Fatalf("Erro ao importar: %v", err)
+	}
+	log.Printf("%d CEPs importados para %s", total, *saida)
 }
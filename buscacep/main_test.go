@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// usarProvedor troca o provedor global até o fim do teste
+func usarProvedor(t *testing.T, p Provider) {
+	t.Helper()
+	anterior := provedor
+	provedor = p
+	t.Cleanup(func() { provedor = anterior })
+}
+
+func TestViaCEPErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		expected error
+	}{
+		{name: "erro booleano", status: http.StatusOK, body: `{"erro": true}`, expected: ErrCEPNotFound},
+		{name: "erro string", status: http.StatusOK, body: `{"erro": "true"}`, expected: ErrCEPNotFound},
+		{name: "cep mal formado", status: http.StatusBadRequest, body: ``, expected: ErrInvalidCEP},
+		{name: "falha no servidor", status: http.StatusServiceUnavailable, body: ``, expected: ErrUpstreamUnavailable},
+		{name: "json inválido", status: http.StatusOK, body: `<html>`, expected: ErrUpstreamUnavailable},
+	}
+
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			w.Write([]byte(c.body))
+		}))
+		viaCEP := ViaCEP{BaseURL: server.URL + "/ws/"}
+
+		_, err := viaCEP.BuscarCep(context.Background(), "01001000")
+		if !errors.Is(err, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, err)
+		}
+		server.Close()
+	}
+}
+
+func TestBuscaCepHandlerStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws/01001000/json/" {
+			w.Write([]byte(`{"cep": "01001-000", "logradouro": "Praça da Sé", "uf": "SP"}`))
+			return
+		}
+		w.Write([]byte(`{"erro": true}`))
+	}))
+	defer server.Close()
+	usarProvedor(t, ViaCEP{BaseURL: server.URL + "/ws/"})
+
+	cases := map[string]int{
+		"/?cep=01001-000": http.StatusOK,
+		"/?cep=99999999":  http.StatusNotFound,
+		"/?cep=../x":      http.StatusBadRequest,
+		"/":               http.StatusBadRequest,
+	}
+
+	for target, expected := range cases {
+		recorder := httptest.NewRecorder()
+		buscaCepHandler(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		if recorder.Code != expected {
+			t.Errorf("GET %s: expected status %d, got %d", target, expected, recorder.Code)
+		}
+	}
+}
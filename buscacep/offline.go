@@ -0,0 +1,257 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Offline responde a partir de um índice local gerado por ImportarCSV,
+// sem nenhum acesso à rede
+type Offline struct {
+	strings   []string
+	registros []registro // ordenados por CEP
+
+	// porCidade agrupa, por UF e cidade normalizadas, os índices dos
+	// registros ordenados pelo logradouro normalizado
+	porCidade map[string][]int
+	// logradouros guarda o logradouro normalizado de cada registro
+	logradouros []string
+}
+
+// registro é um endereço do índice; os campos de texto apontam para Offline.strings
+type registro struct {
+	Cep         uint32
+	Logradouro  uint32
+	Complemento uint32
+	Bairro      uint32
+	Localidade  uint32
+	Uf          uint32
+	Ibge        uint32
+}
+
+// indiceArquivo é o formato gravado em disco: gob comprimido com gzip, com
+// cada texto guardado uma única vez
+type indiceArquivo struct {
+	Strings   []string
+	Registros []registro
+}
+
+// colunasCSV associa os nomes aceitos no cabeçalho aos campos do Endereco
+var colunasCSV = map[string]string{
+	"cep":         "cep",
+	"logradouro":  "logradouro",
+	"complemento": "complemento",
+	"bairro":      "bairro",
+	"cidade":      "localidade",
+	"localidade":  "localidade",
+	"municipio":   "localidade",
+	"uf":          "uf",
+	"ibge":        "ibge",
+}
+
+// ImportarCSV lê um CSV com cabeçalho (cep, logradouro, complemento, bairro,
+// cidade, uf, ibge) e grava o índice compacto em saida. Linhas com CEP
+// inválido são ignoradas; a quantidade importada é devolvida.
+func ImportarCSV(entrada io.Reader, separador rune, saida io.Writer) (int, error) {
+	reader := csv.NewReader(entrada)
+	reader.Comma = separador
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	cabecalho, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("lendo cabeçalho: %w", err)
+	}
+	colunas := map[string]int{}
+	for i, nome := range cabecalho {
+		if campo, ok := colunasCSV[normalizar(nome)]; ok {
+			colunas[campo] = i
+		}
+	}
+	if _, ok := colunas["cep"]; !ok {
+		return 0, errors.New("o cabeçalho não tem a coluna cep")
+	}
+
+	indice := indiceArquivo{}
+	posicoes := map[string]uint32{}
+	texto := func(record []string, campo string) uint32 {
+		i, ok := colunas[campo]
+		if !ok || i >= len(record) {
+			i = -1
+		}
+		valor := ""
+		if i >= 0 {
+			valor = strings.TrimSpace(record[i])
+		}
+		if pos, ok := posicoes[valor]; ok {
+			return pos
+		}
+		pos := uint32(len(indice.Strings))
+		indice.Strings = append(indice.Strings, valor)
+		posicoes[valor] = pos
+		return pos
+	}
+
+	porCep := map[uint32]registro{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if colunas["cep"] >= len(record) {
+			continue
+		}
+		cep, err := ParseCEP(record[colunas["cep"]])
+		if err != nil {
+			continue
+		}
+		numero, _ := strconv.ParseUint(cep.Digits(), 10, 32)
+
+		porCep[uint32(numero)] = registro{
+			Cep:         uint32(numero),
+			Logradouro:  texto(record, "logradouro"),
+			Complemento: texto(record, "complemento"),
+			Bairro:      texto(record, "bairro"),
+			Localidade:  texto(record, "localidade"),
+			Uf:          texto(record, "uf"),
+			Ibge:        texto(record, "ibge"),
+		}
+	}
+
+	for _, r := range porCep {
+		indice.Registros = append(indice.Registros, r)
+	}
+	sort.Slice(indice.Registros, func(i, j int) bool {
+		return indice.Registros[i].Cep < indice.Registros[j].Cep
+	})
+
+	gz := gzip.NewWriter(saida)
+	if err := gob.NewEncoder(gz).Encode(indice); err != nil {
+		return 0, err
+	}
+	return len(indice.Registros), gz.Close()
+}
+
+// AbrirOffline carrega o índice gerado por ImportarCSV
+func AbrirOffline(path string) (*Offline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("índice inválido: %w", err)
+	}
+
+	var indice indiceArquivo
+	if err := gob.NewDecoder(gz).Decode(&indice); err != nil {
+		return nil, fmt.Errorf("índice inválido: %w", err)
+	}
+
+	o := &Offline{
+		strings:     indice.Strings,
+		registros:   indice.Registros,
+		porCidade:   map[string][]int{},
+		logradouros: make([]string, len(indice.Registros)),
+	}
+	for i, r := range o.registros {
+		o.logradouros[i] = normalizar(o.strings[r.Logradouro])
+		chave := chaveCidade(o.strings[r.Uf], o.strings[r.Localidade])
+		o.porCidade[chave] = append(o.porCidade[chave], i)
+	}
+	for _, indices := range o.porCidade {
+		sort.Slice(indices, func(a, b int) bool {
+			return o.logradouros[indices[a]] < o.logradouros[indices[b]]
+		})
+	}
+	return o, nil
+}
+
+func (o *Offline) Name() string {
+	return "offline"
+}
+
+// Len devolve quantos CEPs o índice tem
+func (o *Offline) Len() int {
+	return len(o.registros)
+}
+
+func (o *Offline) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	numero, err := strconv.ParseUint(cep.Digits(), 10, 32)
+	if err != nil {
+		return Endereco{}, ErrInvalidCEP
+	}
+
+	i := sort.Search(len(o.registros), func(i int) bool {
+		return o.registros[i].Cep >= uint32(numero)
+	})
+	if i == len(o.registros) || o.registros[i].Cep != uint32(numero) {
+		return Endereco{}, ErrCEPNotFound
+	}
+	return o.endereco(o.registros[i]), nil
+}
+
+// BuscarPorLogradouro devolve até limite endereços da cidade cujo logradouro
+// começa com prefixo, sem diferenciar maiúsculas nem acentos
+func (o *Offline) BuscarPorLogradouro(uf, cidade, prefixo string, limite int) []Endereco {
+	indices := o.porCidade[chaveCidade(uf, cidade)]
+	prefixo = normalizar(prefixo)
+
+	inicio := sort.Search(len(indices), func(i int) bool {
+		return o.logradouros[indices[i]] >= prefixo
+	})
+
+	var enderecos []Endereco
+	for _, i := range indices[inicio:] {
+		if !strings.HasPrefix(o.logradouros[i], prefixo) || (limite > 0 && len(enderecos) == limite) {
+			break
+		}
+		enderecos = append(enderecos, o.endereco(o.registros[i]))
+	}
+	return enderecos
+}
+
+func (o *Offline) endereco(r registro) Endereco {
+	return Endereco{
+		Cep:         CEP(fmt.Sprintf("%08d", r.Cep)).String(),
+		Logradouro:  o.strings[r.Logradouro],
+		Complemento: o.strings[r.Complemento],
+		Bairro:      o.strings[r.Bairro],
+		Localidade:  o.strings[r.Localidade],
+		Uf:          o.strings[r.Uf],
+		Ibge:        o.strings[r.Ibge],
+	}
+}
+
+func chaveCidade(uf, cidade string) string {
+	return normalizar(uf) + "/" + normalizar(cidade)
+}
+
+var semAcento = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// normalizar deixa o texto em minúsculas, sem acentos e sem espaços sobrando
+func normalizar(s string) string {
+	return semAcento.Replace(strings.Join(strings.Fields(strings.ToLower(s)), " "))
+}
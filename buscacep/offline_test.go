@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const csvDNE = `CEP;Logradouro;Bairro;Cidade;UF;IBGE
+01001-000;Praça da Sé;Sé;São Paulo;SP;3550308
+01310-100;Avenida Paulista;Bela Vista;São Paulo;SP;3550308
+01311-000;Avenida Paulista;Bela Vista;São Paulo;SP;3550308
+01002-000;Rua Direita;Sé;São Paulo;SP;3550308
+20040-020;Avenida Rio Branco;Centro;Rio de Janeiro;RJ;3304557
+inválido;Rua Sem CEP;Centro;Rio de Janeiro;RJ;3304557
+`
+
+func abrirIndiceTeste(t *testing.T) *Offline {
+	var buf bytes.Buffer
+	total, err := ImportarCSV(strings.NewReader(csvDNE), ';', &buf)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if total != 5 {
+		t.Errorf("expected 5 imported CEPs, got %d", total)
+	}
+
+	path := filepath.Join(t.TempDir(), "ceps.idx")
+	os.WriteFile(path, buf.Bytes(), 0o644)
+
+	indice, err := AbrirOffline(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return indice
+}
+
+func TestOfflineBuscarCep(t *testing.T) {
+	indice := abrirIndiceTeste(t)
+
+	endereco, err := indice.BuscarCep(context.Background(), "01310100")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if endereco.Cep != "01310-100" || endereco.Logradouro != "Avenida Paulista" || endereco.Uf != "SP" || endereco.Ibge != "3550308" {
+		t.Errorf("unexpected address %+v", endereco)
+	}
+
+	if _, err := indice.BuscarCep(context.Background(), "99999999"); !errors.Is(err, ErrCEPNotFound) {
+		t.Errorf("expected ErrCEPNotFound, got %v", err)
+	}
+}
+
+func TestOfflineBuscarPorLogradouro(t *testing.T) {
+	indice := abrirIndiceTeste(t)
+
+	enderecos := indice.BuscarPorLogradouro("sp", "sao paulo", "AVENIDA p", 0)
+	if len(enderecos) != 2 {
+		t.Fatalf("expected 2 addresses, got %+v", enderecos)
+	}
+
+	enderecos = indice.BuscarPorLogradouro("SP", "São Paulo", "", 1)
+	if len(enderecos) != 1 {
+		t.Errorf("expected limit to apply, got %d addresses", len(enderecos))
+	}
+
+	if enderecos := indice.BuscarPorLogradouro("RJ", "Rio de Janeiro", "Rua", 0); len(enderecos) != 0 {
+		t.Errorf("expected row with invalid CEP to be skipped, got %+v", enderecos)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Provider é uma fonte de endereços por CEP (ViaCEP, BrasilAPI, arquivo local...)
+type Provider interface {
+	Name() string
+	BuscarCep(ctx context.Context, cep CEP) (Endereco, error)
+}
+
+// Race consulta todos os provedores ao mesmo tempo e devolve a primeira
+// resposta bem-sucedida, cancelando as demais
+type Race struct {
+	Providers []Provider
+	// Timeout limita cada provedor; zero significa sem limite próprio
+	Timeout time.Duration
+}
+
+func (r Race) Name() string {
+	return "race"
+}
+
+func (r Race) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type resultado struct {
+		endereco Endereco
+		err      error
+	}
+
+	resultados := make(chan resultado, len(r.Providers))
+	for _, p := range r.Providers {
+		go func(p Provider) {
+			endereco, err := consultar(ctx, p, cep, r.Timeout)
+			resultados <- resultado{endereco, err}
+		}(p)
+	}
+
+	var errs []error
+	for range r.Providers {
+		res := <-resultados
+		if res.err == nil {
+			return res.endereco, nil
+		}
+		errs = append(errs, res.err)
+	}
+	return Endereco{}, combinarErros(errs)
+}
+
+// Failover consulta os provedores em ordem, passando ao próximo quando um falha
+type Failover struct {
+	Providers []Provider
+	// Timeout limita cada provedor; zero significa sem limite próprio
+	Timeout time.Duration
+}
+
+func (f Failover) Name() string {
+	return "failover"
+}
+
+func (f Failover) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	var errs []error
+	for _, p := range f.Providers {
+		endereco, err := consultar(ctx, p, cep, f.Timeout)
+		if err == nil {
+			return endereco, nil
+		}
+		errs = append(errs, err)
+
+		// O cliente desistiu, não adianta tentar os próximos
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return Endereco{}, combinarErros(errs)
+}
+
+func consultar(ctx context.Context, p Provider, cep CEP, timeout time.Duration) (Endereco, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	endereco, err := p.BuscarCep(ctx, cep)
+	if err != nil {
+		return Endereco{}, fmt.Errorf("%s: %w", p.Name(), err)
+	}
+	return endereco, nil
+}
+
+// combinarErros escolhe o erro mais informativo entre as falhas dos
+// provedores: CEP inválido, depois CEP não encontrado, depois indisponível
+func combinarErros(errs []error) error {
+	if len(errs) == 0 {
+		return ErrUpstreamUnavailable
+	}
+	for _, alvo := range []error{ErrInvalidCEP, ErrCEPNotFound} {
+		for _, err := range errs {
+			if errors.Is(err, alvo) {
+				return err
+			}
+		}
+	}
+	return errors.Join(append([]error{ErrUpstreamUnavailable}, errs...)...)
+}
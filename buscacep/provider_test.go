@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeServer responde com body após delay, ou desiste se a requisição for cancelada
+func fakeServer(t *testing.T, status int, body string, delay time.Duration, cancelled chan<- struct{}) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		case <-r.Context().Done():
+			if cancelled != nil {
+				cancelled <- struct{}{}
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRaceReturnsFastestAndCancelsOthers(t *testing.T) {
+	cancelled := make(chan struct{}, 1)
+	slow := fakeServer(t, http.StatusOK, `{"cep": "01001-000", "logradouro": "lento"}`, 2*time.Second, cancelled)
+	fast := fakeServer(t, http.StatusOK, `{"cep": "01001000", "street": "Praça da Sé", "state": "SP"}`, 0, nil)
+
+	race := Race{Providers: []Provider{
+		ViaCEP{BaseURL: slow.URL + "/ws/"},
+		BrasilAPI{BaseURL: fast.URL + "/api/cep/v1/"},
+	}}
+
+	endereco, err := race.BuscarCep(context.Background(), "01001000")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if endereco.Logradouro != "Praça da Sé" || endereco.Cep != "01001-000" {
+		t.Errorf("expected the fast provider's address, got %+v", endereco)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("slow provider was not cancelled")
+	}
+}
+
+func TestRaceIgnoresFailuresUntilSuccess(t *testing.T) {
+	broken := fakeServer(t, http.StatusInternalServerError, ``, 0, nil)
+	ok := fakeServer(t, http.StatusOK, `{"cep": "01001-000", "logradouro": "Praça da Sé"}`, 50*time.Millisecond, nil)
+
+	race := Race{Providers: []Provider{
+		BrasilAPI{BaseURL: broken.URL + "/"},
+		ViaCEP{BaseURL: ok.URL + "/ws/"},
+	}}
+
+	endereco, err := race.BuscarCep(context.Background(), "01001000")
+	if err != nil || endereco.Logradouro != "Praça da Sé" {
+		t.Errorf("expected address from second provider, got %+v, %v", endereco, err)
+	}
+}
+
+func TestFailoverTimeoutMovesToNextProvider(t *testing.T) {
+	slow := fakeServer(t, http.StatusOK, `{"cep": "01001-000"}`, time.Second, nil)
+	ok := fakeServer(t, http.StatusOK, `{"cep": "01001-000", "logradouro": "Praça da Sé"}`, 0, nil)
+
+	failover := Failover{
+		Providers: []Provider{ViaCEP{BaseURL: slow.URL + "/ws/"}, ViaCEP{BaseURL: ok.URL + "/ws/"}},
+		Timeout:   50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	endereco, err := failover.BuscarCep(context.Background(), "01001000")
+	if err != nil || endereco.Logradouro != "Praça da Sé" {
+		t.Errorf("expected address from second provider, got %+v, %v", endereco, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("per-provider timeout not applied, took %v", elapsed)
+	}
+}
+
+func TestFailoverAllFail(t *testing.T) {
+	notFound := fakeServer(t, http.StatusNotFound, ``, 0, nil)
+	broken := fakeServer(t, http.StatusBadGateway, ``, 0, nil)
+
+	failover := Failover{Providers: []Provider{
+		BrasilAPI{BaseURL: broken.URL + "/"},
+		BrasilAPI{BaseURL: notFound.URL + "/"},
+	}}
+	if _, err := failover.BuscarCep(context.Background(), "99999999"); !errors.Is(err, ErrCEPNotFound) {
+		t.Errorf("expected ErrCEPNotFound, got %v", err)
+	}
+
+	failover.Providers = failover.Providers[:1]
+	if _, err := failover.BuscarCep(context.Background(), "99999999"); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("expected ErrUpstreamUnavailable, got %v", err)
+	}
+}
+
+func TestArquivo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enderecos.json")
+	os.WriteFile(path, []byte(`[{"cep": "01001-000", "logradouro": "Praça da Sé"}]`), 0o644)
+
+	arquivo := &Arquivo{Path: path}
+	endereco, err := arquivo.BuscarCep(context.Background(), "01001000")
+	if err != nil || endereco.Logradouro != "Praça da Sé" {
+		t.Errorf("expected address from file, got %+v, %v", endereco, err)
+	}
+	if _, err := arquivo.BuscarCep(context.Background(), "99999999"); !errors.Is(err, ErrCEPNotFound) {
+		t.Errorf("expected ErrCEPNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func escreverCertificado(t *testing.T, certPath, keyPath string) *tls.Certificate {
+	cert, err := gerarCertificadoAutoassinado()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o644)
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600)
+	return &cert
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	first := escreverCertificado(t, certPath, keyPath)
+	reloader, err := newCertReloader(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	second := escreverCertificado(t, certPath, keyPath)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certPath, future, future)
+	if err := reloader.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	current, _ := reloader.GetCertificate(nil)
+	if string(current.Certificate[0]) == string(first.Certificate[0]) {
+		t.Error("expected the new certificate after reload")
+	}
+	if string(current.Certificate[0]) != string(second.Certificate[0]) {
+		t.Error("reloaded certificate does not match the file")
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	cases := map[string]string{
+		":8443": "https://example.com:8443/batch?x=1",
+		":443":  "https://example.com/batch?x=1",
+	}
+
+	for addr, expected := range cases {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "http://example.com:8080/batch?x=1", nil)
+		redirectToHTTPS(addr).ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusMovedPermanently || recorder.Header().Get("Location") != expected {
+			t.Errorf("%s: expected redirect to %s, got %d %s", addr, expected, recorder.Code, recorder.Header().Get("Location"))
+		}
+	}
+}
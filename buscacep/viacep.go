@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ViaCEP consulta https://viacep.com.br
+type ViaCEP struct {
+	// BaseURL permite apontar para outro servidor, como um httptest.Server
+	BaseURL string
+	Client  *http.Client
+}
+
+func (v ViaCEP) Name() string {
+	return "viacep"
+}
+
+func (v ViaCEP) BuscarCep(ctx context.Context, cep CEP) (Endereco, error) {
+	baseURL := v.BaseURL
+	if baseURL == "" {
+		baseURL = "https://viacep.com.br/ws/"
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+cep.Digits()+"/json/", nil)
+	if err != nil {
+		return Endereco{}, err
+	}
+
+	response, err := httpClient(v.Client).Do(request)
+	if err != nil {
+		return Endereco{}, fmt.Errorf("%w: %v", ErrUpstreamUnavailable, err)
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest:
+		return Endereco{}, ErrInvalidCEP
+	default:
+		return Endereco{}, fmt.Errorf("%w: status %d", ErrUpstreamUnavailable, response.StatusCode)
+	}
+
+	// Para CEPs inexistentes a ViaCEP responde 200 com {"erro": true}
+	// (ou "true", dependendo da versão da API)
+	var resposta struct {
+		Endereco
+		Erro interface{} `json:"erro"`
+	}
+
+	err = json.NewDecoder(response.Body).Decode(&resposta)
+	if err != nil {
+		return Endereco{}, fmt.Errorf("%w: resposta inválida: %v", ErrUpstreamUnavailable, err)
+	}
+
+	if resposta.Erro == true || resposta.Erro == "true" {
+		return Endereco{}, ErrCEPNotFound
+	}
+
+	return resposta.Endereco, nil
+}
+
+func httpClient(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
@@ -0,0 +1,215 @@
+// Package deadline gives each route its own time budget. The request
+// context carries the deadline into downstream calls, expired requests get
+// a JSON 504 (or 503 when the request was cancelled from outside), and the
+// log says which stage of the handler the time went to.
+package deadline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guilhermehermes/curso-go/context/reqctx"
+)
+
+// ErrorResponse is the JSON body written when a request expires
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Stage   string `json:"stage,omitempty"`
+}
+
+// Handler runs h with a deadline of d. h should pass r.Context() to every
+// downstream call (database queries, outbound requests) so they stop when
+// the deadline expires. If h has not finished by then, the client gets a
+// 504 and whatever h writes afterwards is discarded.
+func Handler(h http.Handler, d time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		stages := &tracker{ctx: ctx}
+		ctx = context.WithValue(ctx, trackerKey{}, stages)
+
+		tw := &timeoutWriter{header: http.Header{}}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		start := time.Now()
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			h.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+		case <-ctx.Done():
+		}
+
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		// h may also have finished after the deadline, when both cases
+		// were ready; its response is late either way
+		if ctx.Err() == nil {
+			for key, values := range tw.header {
+				w.Header()[key] = values
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.body.Bytes())
+			return
+		}
+		tw.expired = true
+
+		status, code := http.StatusGatewayTimeout, "timeout"
+		message := fmt.Sprintf("request exceeded its %v deadline", d)
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) || r.Context().Err() != nil {
+			// Cancelled from outside: the server is shutting down or
+			// the client went away
+			status, code = http.StatusServiceUnavailable, "cancelled"
+			message = "request cancelled before completion"
+		}
+
+		current, summary := stages.report()
+		reqctx.Logger(r.Context()).Warn("request expired",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"reason", code,
+			"elapsed", time.Since(start).Round(time.Millisecond),
+			"stage", current,
+			"stages", summary,
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: code, Message: message, Stage: current})
+	})
+}
+
+// Middleware is Handler for middleware chains
+func Middleware(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return Handler(next, d)
+	}
+}
+
+// Stage marks the start of a named step of the handler, such as "db" or
+// "upstream", and returns the function that marks its end:
+//
+//	defer deadline.Stage(ctx, "db")()
+//
+// Outside of Handler it does nothing.
+func Stage(ctx context.Context, name string) func() {
+	stages, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return func() {}
+	}
+	return stages.begin(name)
+}
+
+type trackerKey struct{}
+
+// tracker records the stages of one request
+type tracker struct {
+	// ctx is the request's deadline context
+	ctx context.Context
+
+	mu     sync.Mutex
+	stages []stage
+}
+
+type stage struct {
+	name       string
+	start, end time.Time
+	// late is true when the stage ended after ctx did, e.g. a downstream
+	// call that returned because of the deadline
+	late bool
+}
+
+func (t *tracker) begin(name string) func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stages = append(t.stages, stage{name: name, start: time.Now()})
+	i := len(t.stages) - 1
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.stages[i].end = time.Now()
+		t.stages[i].late = t.ctx.Err() != nil
+	}
+}
+
+// report returns the last stage that was still running when ctx ended and
+// how long every stage took, e.g. "db 120ms, upstream 880ms (running)"
+func (t *tracker) report() (string, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.stages) == 0 {
+		return "", "no stages recorded"
+	}
+	current := ""
+	parts := make([]string, 0, len(t.stages))
+	for _, s := range t.stages {
+		switch {
+		case s.end.IsZero():
+			current = s.name
+			parts = append(parts, fmt.Sprintf("%s %v (running)", s.name, time.Since(s.start).Round(time.Millisecond)))
+		case s.late:
+			current = s.name
+			parts = append(parts, fmt.Sprintf("%s %v (running)", s.name, s.end.Sub(s.start).Round(time.Millisecond)))
+		default:
+			parts = append(parts, fmt.Sprintf("%s %v", s.name, s.end.Sub(s.start).Round(time.Millisecond)))
+		}
+	}
+	return current, strings.Join(parts, ", ")
+}
+
+// timeoutWriter buffers the response so it can be dropped if the deadline
+// expires first
+type timeoutWriter struct {
+	mu      sync.Mutex
+	header  http.Header
+	body    bytes.Buffer
+	status  int
+	expired bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.status == 0 && !tw.expired {
+		tw.status = status
+	}
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(b)
+}
@@ -0,0 +1,68 @@
+package deadline
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandlerFinishesInTime(t *testing.T) {
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("expected the request context to have a deadline")
+		}
+		w.Header().Set("X-Done", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}), time.Second)
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("POST", "/", nil))
+	if response.Code != http.StatusCreated || response.Body.String() != "created" || response.Header().Get("X-Done") != "yes" {
+		t.Errorf("unexpected response %d %q %v", response.Code, response.Body.String(), response.Header())
+	}
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	downstreamCancelled := make(chan struct{})
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Stage(r.Context(), "db")()
+		defer Stage(r.Context(), "upstream")()
+
+		// A downstream call that honours the context
+		<-r.Context().Done()
+		close(downstreamCancelled)
+		w.Write([]byte("too late"))
+	}), 20*time.Millisecond)
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/report", nil))
+
+	var body ErrorResponse
+	json.NewDecoder(response.Body).Decode(&body)
+	if response.Code != http.StatusGatewayTimeout || body.Error != "timeout" || body.Stage != "upstream" {
+		t.Errorf("unexpected response %d %+v", response.Code, body)
+	}
+	select {
+	case <-downstreamCancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the downstream call to see the cancellation")
+	}
+}
+
+func TestHandlerCancelledFromOutside(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	}), time.Second)
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	if response.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", response.Code)
+	}
+}
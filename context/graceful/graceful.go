@@ -0,0 +1,209 @@
+// Package graceful runs an http.Server until SIGINT or SIGTERM, then drains
+// in-flight requests within a grace period and runs cleanup hooks in order.
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrForced is returned, wrapped, when the grace period expired and the
+// remaining connections were closed
+var ErrForced = errors.New("graceful: grace period expired")
+
+// Runner owns an http.Server for its whole lifetime
+type Runner struct {
+	Server *http.Server
+	// GracePeriod is how long in-flight requests may take to finish once
+	// shutdown starts (default 10s). Request contexts are cancelled when it
+	// expires, so handlers that watch r.Context() can stop early.
+	GracePeriod time.Duration
+	// HookTimeout bounds each cleanup hook (default 5s)
+	HookTimeout time.Duration
+	// Signals that start the shutdown (default SIGINT and SIGTERM). A second
+	// signal during the drain ends the process immediately.
+	Signals []os.Signal
+	// OnSignal, when set, runs as soon as shutdown starts and before
+	// connections are drained, e.g. to fail readiness probes
+	OnSignal func()
+	// Logger defaults to log.Default()
+	Logger *log.Logger
+
+	mu    sync.Mutex
+	hooks []hook
+
+	active atomic.Int64
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// OnShutdown registers a cleanup hook. Hooks run after the server stopped,
+// in registration order, and each gets its own HookTimeout.
+func (r *Runner) OnShutdown(name string, fn func(ctx context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook{name, fn})
+}
+
+// Run listens on Server.Addr and serves until a signal arrives or ctx is
+// cancelled, then shuts down. It returns nil after a clean shutdown.
+func (r *Runner) Run(ctx context.Context) error {
+	addr := r.Server.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return r.Serve(ctx, listener)
+}
+
+// Serve is Run with an existing listener
+func (r *Runner) Serve(ctx context.Context, listener net.Listener) error {
+	r.defaults()
+	logger := r.Logger
+
+	signals := r.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, stop := signal.NotifyContext(ctx, signals...)
+	defer stop()
+
+	// Request contexts derive from base, which is cancelled when the grace
+	// period runs out
+	base, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	r.cancelWith(base)
+	r.trackConnections()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Printf("Listening on %s", listener.Addr())
+		serveErr <- r.Server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// The server failed on its own; there is nothing to drain
+		return errors.Join(err, r.runHooks())
+	case <-ctx.Done():
+	}
+
+	// Restore default signal handling, so a second Ctrl+C kills the process
+	stop()
+	logger.Printf("Shutting down: draining %d active connections (grace period %v)", r.active.Load(), r.GracePeriod)
+	if r.OnSignal != nil {
+		r.OnSignal()
+	}
+
+	var forced error
+	drainCtx, cancel := context.WithTimeout(context.Background(), r.GracePeriod)
+	defer cancel()
+	if err := r.Server.Shutdown(drainCtx); err != nil {
+		remaining := r.active.Load()
+		cancelRequests()
+		r.Server.Close()
+		forced = fmt.Errorf("%w: forced %d connections closed", ErrForced, remaining)
+		logger.Print(forced)
+	} else {
+		logger.Print("All connections drained")
+	}
+
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		forced = errors.Join(forced, err)
+	}
+	return errors.Join(forced, r.runHooks())
+}
+
+func (r *Runner) defaults() {
+	if r.GracePeriod <= 0 {
+		r.GracePeriod = 10 * time.Second
+	}
+	if r.HookTimeout <= 0 {
+		r.HookTimeout = 5 * time.Second
+	}
+	if r.Logger == nil {
+		r.Logger = log.Default()
+	}
+}
+
+// trackConnections counts connections that are serving a request, chaining
+// any ConnState callback already set on the server
+// cancelWith makes request contexts end when base does, keeping the values
+// and cancellation of a BaseContext the caller already set
+func (r *Runner) cancelWith(base context.Context) {
+	previous := r.Server.BaseContext
+	if previous == nil {
+		r.Server.BaseContext = func(net.Listener) context.Context { return base }
+		return
+	}
+
+	r.Server.BaseContext = func(listener net.Listener) context.Context {
+		ctx, cancel := context.WithCancel(previous(listener))
+		context.AfterFunc(base, cancel)
+		return ctx
+	}
+}
+
+func (r *Runner) trackConnections() {
+	previous := r.Server.ConnState
+	var mu sync.Mutex
+	active := map[net.Conn]bool{}
+
+	r.Server.ConnState = func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		switch state {
+		case http.StateActive:
+			if !active[conn] {
+				active[conn] = true
+				r.active.Add(1)
+			}
+		case http.StateIdle, http.StateHijacked, http.StateClosed:
+			if active[conn] {
+				delete(active, conn)
+				r.active.Add(-1)
+			}
+		}
+		mu.Unlock()
+
+		if previous != nil {
+			previous(conn, state)
+		}
+	}
+}
+
+// runHooks runs every hook in order, even after one fails
+func (r *Runner) runHooks() error {
+	r.mu.Lock()
+	hooks := append([]hook(nil), r.hooks...)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, h := range hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), r.HookTimeout)
+		err := h.fn(ctx)
+		cancel()
+		if err != nil {
+			r.Logger.Printf("Cleanup %s failed: %v", h.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		r.Logger.Printf("Cleanup %s done", h.name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,146 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// start serves handler with a Runner until the returned cancel is called
+func start(t *testing.T, runner *Runner, handler http.Handler) (string, context.CancelFunc, chan error) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runner.Server == nil {
+		runner.Server = &http.Server{}
+	}
+	runner.Server.Handler = handler
+	runner.Logger = log.New(io.Discard, "", 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runner.Serve(ctx, listener) }()
+	return "http://" + listener.Addr().String(), cancel, done
+}
+
+func TestDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	var order []string
+	runner := &Runner{GracePeriod: 2 * time.Second}
+	runner.OnSignal = func() { order = append(order, "signal") }
+	runner.OnShutdown("first", func(ctx context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	runner.OnShutdown("second", func(ctx context.Context) error {
+		order = append(order, "second")
+		return errors.New("disk full")
+	})
+	runner.OnShutdown("third", func(ctx context.Context) error {
+		order = append(order, "third")
+		return nil
+	})
+
+	url, shutdown, done := start(t, runner, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "finished")
+	}))
+
+	body := make(chan string, 1)
+	go func() {
+		response, err := http.Get(url)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		data, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		body <- string(data)
+	}()
+
+	<-started
+	shutdown()
+
+	if got := <-body; got != "finished" {
+		t.Errorf("expected in-flight request to finish, got %q", got)
+	}
+	err := <-done
+	if errors.Is(err, ErrForced) || err == nil || !strings.Contains(err.Error(), "second: disk full") {
+		t.Errorf("expected only the hook error, got %v", err)
+	}
+	if strings.Join(order, ",") != "signal,first,second,third" {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestForcesAfterGracePeriod(t *testing.T) {
+	servers := map[string]*http.Server{
+		"default": nil,
+		// A BaseContext set by the caller is cancelled too
+		"caller BaseContext": {BaseContext: func(net.Listener) context.Context { return context.Background() }},
+	}
+	for name, server := range servers {
+		t.Run(name, func(t *testing.T) {
+			started := make(chan struct{})
+			cancelled := make(chan struct{})
+			runner := &Runner{Server: server, GracePeriod: 50 * time.Millisecond}
+			url, shutdown, done := start(t, runner, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-r.Context().Done()
+				close(cancelled)
+			}))
+
+			go http.Get(url)
+			<-started
+			shutdown()
+
+			err := <-done
+			if !errors.Is(err, ErrForced) || !strings.Contains(err.Error(), "1 connections") {
+				t.Errorf("expected forced shutdown of 1 connection, got %v", err)
+			}
+			select {
+			case <-cancelled:
+			case <-time.After(time.Second):
+				t.Error("expected the request context to be cancelled")
+			}
+		})
+	}
+}
+
+type baseKey struct{}
+
+func TestKeepsCallerBaseContext(t *testing.T) {
+	runner := &Runner{Server: &http.Server{
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithValue(context.Background(), baseKey{}, "caller")
+		},
+	}}
+	url, shutdown, done := start(t, runner, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, _ := r.Context().Value(baseKey{}).(string)
+		io.WriteString(w, value)
+	}))
+
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != "caller" {
+		t.Errorf("expected the caller's BaseContext value, got %q", body)
+	}
+
+	shutdown()
+	if err := <-done; err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+}
@@ -2,17 +2,27 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/guilhermehermes/curso-go/context/deadline"
+	"github.com/guilhermehermes/curso-go/context/graceful"
+	"github.com/guilhermehermes/curso-go/context/reqctx"
+	"github.com/guilhermehermes/curso-go/observability"
 )
 
 func handler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	log.Println("handler started")
-	defer log.Println("handler ended")
+	logger := reqctx.Logger(ctx)
+	logger.Info("handler started")
+	defer logger.Info("handler ended")
 
+	defer deadline.Stage(ctx, "work")()
 	select {
 	case <-time.After(5 * time.Second):
 		fmt.Fprintln(w, "Request processed")
@@ -22,29 +32,90 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// reportHandler runs a simulated query and an outbound request, both bound
+// to the request deadline
+func reportHandler(upstream string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		rows, err := queryDB(ctx, 300*time.Millisecond)
+		if err != nil {
+			http.Error(w, "query failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		status, err := fetchUpstream(ctx, upstream)
+		if err != nil {
+			http.Error(w, "upstream failed: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		fmt.Fprintf(w, "Report: %d rows, upstream answered %d\n", rows, status)
+	}
+}
+
+// queryDB stands in for a database call that honours ctx, as
+// db.QueryContext does
+func queryDB(ctx context.Context, latency time.Duration) (int, error) {
+	defer deadline.Stage(ctx, "db")()
+	select {
+	case <-time.After(latency):
+		return 42, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// fetchUpstream makes an outbound request that is cancelled with ctx
+func fetchUpstream(ctx context.Context, url string) (int, error) {
+	defer deadline.Stage(ctx, "upstream")()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, err = io.Copy(io.Discard, response.Body)
+	return response.StatusCode, err
+}
+
 func main() {
-	http.HandleFunc("/", handler)
+	addr := flag.String("addr", ":8080", "listen address")
+	grace := flag.Duration("grace", 10*time.Second, "how long in-flight requests may finish after SIGINT/SIGTERM")
+	workTimeout := flag.Duration("timeout", 10*time.Second, "deadline of GET /, whose work takes 5s")
+	reportTimeout := flag.Duration("report-timeout", 2*time.Second, "deadline of GET /report")
+	upstream := flag.String("upstream", "https://jsonplaceholder.typicode.com/posts/1", "URL fetched by GET /report")
+	flag.Parse()
+
+	// Each route gets its own deadline; expired requests answer 504
+	mux := http.NewServeMux()
+	mux.Handle("/", deadline.Handler(http.HandlerFunc(handler), *workTimeout))
+	mux.Handle("GET /report", deadline.Handler(reportHandler(*upstream), *reportTimeout))
+
+	// /healthz, /readyz and /metrics
+	obs := observability.New()
+	obs.Mount(mux)
 
-	server := &http.Server{
-		Addr: ":8080",
+	runner := &graceful.Runner{
+		Server: &http.Server{
+			Addr: *addr,
+			// reqctx fills request ID, user, tenant and logger from the headers;
+			// obs wraps the mux directly so it sees the matched route
+			Handler: reqctx.Middleware(slog.Default())(obs.Middleware(mux)),
+		},
+		GracePeriod: *grace,
+		// Fail readiness first so load balancers stop sending new requests
+		OnSignal: func() { obs.SetReady(false) },
 	}
+	runner.OnShutdown("metrics", func(ctx context.Context) error {
+		return obs.WriteMetrics(log.Writer())
+	})
 
-	go func() {
-		log.Println("Starting server on :8080")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("ListenAndServe(): %v", err)
-		}
-	}()
-
-	// Graceful shutdown on interrupt signal
-	stop := make(chan struct{})
-	go func() {
-		time.Sleep(30 * time.Second)
-		log.Println("Shutting down server...")
-		server.Shutdown(context.Background())
-		close(stop)
-	}()
-
-	<-stop
+	if err := runner.Run(context.Background()); err != nil {
+		log.Fatalf("Server stopped with errors: %v", err)
+	}
 	log.Println("Server stopped")
 }
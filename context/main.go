@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/guilhermehermes/curso-go/context/reqctx"
 )
 
 func doWork(ctx context.Context, name string) {
@@ -28,13 +30,14 @@ func main() {
 	go doWork(ctx2, "worker2")
 	time.Sleep(2 * time.Second)
 
-	// Example 3: context.WithValue
-	ctx3 := context.WithValue(context.Background(), "userID", 42)
+	// Example 3: context.WithValue, through a typed key so the value cannot
+	// collide with another package's "userID" and comes back as an int64
+	ctx3 := reqctx.WithUserID(context.Background(), 42)
 	printUserID(ctx3)
 }
 
 func printUserID(ctx context.Context) {
-	if v := ctx.Value("userID"); v != nil {
-		fmt.Println("userID from context:", v)
+	if userID, ok := reqctx.UserID(ctx); ok {
+		fmt.Println("userID from context:", userID)
 	}
 }
@@ -0,0 +1,142 @@
+// Package reqctx stores request-scoped values in a context.Context under
+// typed keys, so values cannot collide across packages and are read back
+// with their own type instead of interface{}.
+package reqctx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// Key identifies a value of type T. Keys compare by identity, so two
+// packages using the same name still get different keys; keep them in
+// unexported variables.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a new key; name is only used for debugging
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+func (k *Key[T]) String() string {
+	return "reqctx." + k.name
+}
+
+// WithValue returns a copy of ctx carrying value under key
+func WithValue[T any](ctx context.Context, key *Key[T], value T) context.Context {
+	return context.WithValue(ctx, key, value)
+}
+
+// Value returns the value stored under key and whether there was one
+func Value[T any](ctx context.Context, key *Key[T]) (T, bool) {
+	value, ok := ctx.Value(key).(T)
+	return value, ok
+}
+
+var (
+	requestIDKey = NewKey[string]("request_id")
+	userIDKey    = NewKey[int64]("user_id")
+	tenantKey    = NewKey[string]("tenant")
+	loggerKey    = NewKey[*slog.Logger]("logger")
+)
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID, or "" when there is none
+func RequestID(ctx context.Context) string {
+	id, _ := Value(ctx, requestIDKey)
+	return id
+}
+
+func WithUserID(ctx context.Context, id int64) context.Context {
+	return WithValue(ctx, userIDKey, id)
+}
+
+// UserID returns the authenticated user's ID and whether there is one
+func UserID(ctx context.Context) (int64, bool) {
+	return Value(ctx, userIDKey)
+}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return WithValue(ctx, tenantKey, tenant)
+}
+
+// Tenant returns the tenant, or "" when there is none
+func Tenant(ctx context.Context) string {
+	tenant, _ := Value(ctx, tenantKey)
+	return tenant
+}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return WithValue(ctx, loggerKey, logger)
+}
+
+// Logger returns the request logger, or slog.Default() when there is none
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := Value(ctx, loggerKey); ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Headers read by Middleware
+const (
+	RequestIDHeader = "X-Request-ID"
+	UserIDHeader    = "X-User-ID"
+	TenantHeader    = "X-Tenant-ID"
+)
+
+// Middleware fills the request context from the X-Request-ID, X-User-ID and
+// X-Tenant-ID headers, generating a request ID when the client sent none,
+// and stores a logger that tags every line with them. An X-User-ID that is
+// not a number is rejected with 400.
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" || len(id) > 128 {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			ctx = WithRequestID(ctx, id)
+			attrs := []any{"request_id", id}
+
+			if value := r.Header.Get(UserIDHeader); value != "" {
+				userID, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					http.Error(w, "invalid "+UserIDHeader, http.StatusBadRequest)
+					return
+				}
+				ctx = WithUserID(ctx, userID)
+				attrs = append(attrs, "user_id", userID)
+			}
+
+			if tenant := r.Header.Get(TenantHeader); tenant != "" {
+				ctx = WithTenant(ctx, tenant)
+				attrs = append(attrs, "tenant", tenant)
+			}
+
+			ctx = WithLogger(ctx, logger.With(attrs...))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,78 @@
+package reqctx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKeysDoNotCollide(t *testing.T) {
+	// Same name and type, different keys
+	a := NewKey[string]("name")
+	b := NewKey[string]("name")
+
+	ctx := WithValue(context.Background(), a, "from a")
+	if _, ok := Value(ctx, b); ok {
+		t.Error("expected keys with the same name to be distinct")
+	}
+	if value, ok := Value(ctx, a); !ok || value != "from a" {
+		t.Errorf("expected %q, got %q %v", "from a", value, ok)
+	}
+
+	if _, ok := UserID(context.Background()); ok {
+		t.Error("expected no user ID in an empty context")
+	}
+	if Logger(context.Background()) != slog.Default() {
+		t.Error("expected the default logger in an empty context")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	var userID int64
+	var tenant, requestID string
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID, _ = UserID(ctx)
+		tenant = Tenant(ctx)
+		requestID = RequestID(ctx)
+		Logger(ctx).Info("hello")
+	}))
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set(UserIDHeader, "42")
+	request.Header.Set(TenantHeader, "acme")
+	request.Header.Set(RequestIDHeader, "req-1")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if userID != 42 || tenant != "acme" || requestID != "req-1" || response.Header().Get(RequestIDHeader) != "req-1" {
+		t.Errorf("unexpected values: user %d, tenant %q, request %q", userID, tenant, requestID)
+	}
+	var line map[string]interface{}
+	json.Unmarshal(logs.Bytes(), &line)
+	if line["user_id"] != float64(42) || line["tenant"] != "acme" || line["request_id"] != "req-1" {
+		t.Errorf("expected log line tagged with request values, got %s", logs.String())
+	}
+
+	request = httptest.NewRequest("GET", "/", nil)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if requestID == "" || requestID == "req-1" {
+		t.Errorf("expected a generated request ID, got %q", requestID)
+	}
+
+	request = httptest.NewRequest("GET", "/", nil)
+	request.Header.Set(UserIDHeader, "forty-two")
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a non-numeric user ID, got %d", response.Code)
+	}
+}
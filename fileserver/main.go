@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+
+	"github.com/guilhermehermes/curso-go/observability"
 )
 
 func main() {
@@ -10,9 +14,17 @@ func main() {
 	mux := http.NewServeMux()
 	mux.Handle("/", fileServer)
 
+	// /healthz, /readyz and /metrics; ready only while ./static exists
+	obs := observability.New()
+	obs.AddCheck("static", func(ctx context.Context) error {
+		_, err := os.Stat("./static")
+		return err
+	})
+	obs.Mount(mux)
+
 	server := &http.Server{
 		Addr:    ":8080",
-		Handler: mux,
+		Handler: obs.Middleware(mux),
 	}
 
 	log.Println("Starting server on :8080")
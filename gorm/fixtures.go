@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Fixtures is the declarative seed data format. Rows reference each other
+// through their "ref" keys, so the file never mentions database IDs.
+type Fixtures struct {
+	Categories []CategoryFixture `json:"categories"`
+	Products   []ProductFixture  `json:"products"`
+	Languages  []LanguageFixture `json:"languages"`
+	Users      []UserFixture     `json:"users"`
+	Orders     []OrderFixture    `json:"orders"`
+}
+
+type CategoryFixture struct {
+	Ref         string `json:"ref"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ProductFixture struct {
+	Ref         string  `json:"ref"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Category    string  `json:"category"`
+}
+
+type LanguageFixture struct {
+	Ref  string `json:"ref"`
+	Name string `json:"name"`
+}
+
+type UserFixture struct {
+	Ref         string                `json:"ref"`
+	Name        string                `json:"name"`
+	Email       string                `json:"email"`
+	Age         uint8                 `json:"age"`
+	Profile     *ProfileFixture       `json:"profile"`
+	CreditCards []string              `json:"credit_cards"`
+	Languages   []UserLanguageFixture `json:"languages"`
+}
+
+type ProfileFixture struct {
+	Bio         string `json:"bio"`
+	PhoneNumber string `json:"phone_number"`
+	Address     string `json:"address"`
+}
+
+type UserLanguageFixture struct {
+	Language          string     `json:"language"`
+	Level             string     `json:"level"`
+	YearsOfExperience int        `json:"years_of_experience"`
+	CertifiedAt       *time.Time `json:"certified_at"`
+}
+
+type OrderFixture struct {
+	OrderNumber string             `json:"order_number"`
+	User        string             `json:"user"`
+	Status      string             `json:"status"`
+	Items       []OrderItemFixture `json:"items"`
+}
+
+type OrderItemFixture struct {
+	Product  string `json:"product"`
+	Quantity int    `json:"quantity"`
+}
+
+// LoadFixturesFile reads a fixtures file and loads it with LoadFixtures
+func LoadFixturesFile(db *gorm.DB, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var fixtures Fixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return fmt.Errorf("fixtures: parsing %s: %w", path, err)
+	}
+	return LoadFixtures(db, fixtures)
+}
+
+// LoadFixtures upserts every fixture in a single transaction. Rows are matched
+// by their natural key (category, product and language name, user email,
+// card number, order number), so loading the same fixtures twice is a no-op.
+func LoadFixtures(db *gorm.DB, fixtures Fixtures) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		categories := map[string]uint{}
+		for _, f := range fixtures.Categories {
+			category := Category{}
+			err := upsert(tx, &category, Category{Name: f.Name}, Category{Description: f.Description})
+			if err != nil {
+				return fmt.Errorf("fixtures: category %q: %w", f.Ref, err)
+			}
+			categories[f.Ref] = category.ID
+		}
+
+		products := map[string]Product{}
+		for _, f := range fixtures.Products {
+			categoryID, ok := categories[f.Category]
+			if !ok {
+				return fmt.Errorf("fixtures: product %q references unknown category %q", f.Ref, f.Category)
+			}
+			product := Product{}
+			err := upsert(tx, &product, Product{Name: f.Name},
+				Product{Description: f.Description, Price: f.Price, CategoryID: categoryID})
+			if err != nil {
+				return fmt.Errorf("fixtures: product %q: %w", f.Ref, err)
+			}
+			products[f.Ref] = product
+		}
+
+		languages := map[string]uint{}
+		for _, f := range fixtures.Languages {
+			language := Language{}
+			if err := upsert(tx, &language, Language{Name: f.Name}, nil); err != nil {
+				return fmt.Errorf("fixtures: language %q: %w", f.Ref, err)
+			}
+			languages[f.Ref] = language.ID
+		}
+
+		users := map[string]uint{}
+		for _, f := range fixtures.Users {
+			user := User{}
+			err := upsert(tx, &user, User{Email: f.Email}, User{Name: f.Name, Age: f.Age})
+			if err != nil {
+				return fmt.Errorf("fixtures: user %q: %w", f.Ref, err)
+			}
+			users[f.Ref] = user.ID
+
+			if f.Profile != nil {
+				err := upsert(tx, &Profile{}, Profile{UserID: user.ID},
+					Profile{Bio: f.Profile.Bio, PhoneNumber: f.Profile.PhoneNumber, Address: f.Profile.Address})
+				if err != nil {
+					return fmt.Errorf("fixtures: profile of user %q: %w", f.Ref, err)
+				}
+			}
+
+			for _, number := range f.CreditCards {
+				if err := upsert(tx, &CreditCard{}, CreditCard{UserID: user.ID, Number: number}, nil); err != nil {
+					return fmt.Errorf("fixtures: credit card of user %q: %w", f.Ref, err)
+				}
+			}
+
+			for _, l := range f.Languages {
+				languageID, ok := languages[l.Language]
+				if !ok {
+					return fmt.Errorf("fixtures: user %q references unknown language %q", f.Ref, l.Language)
+				}
+				level, err := ParseProficiencyLevel(l.Level)
+				if err != nil {
+					return fmt.Errorf("fixtures: user %q: %w", f.Ref, err)
+				}
+				err = SetLanguageProficiency(tx, user.ID, languageID, Proficiency{
+					Level:             level,
+					YearsOfExperience: l.YearsOfExperience,
+					CertifiedAt:       l.CertifiedAt,
+				})
+				if err != nil {
+					return fmt.Errorf("fixtures: languages of user %q: %w", f.Ref, err)
+				}
+			}
+		}
+
+		for _, f := range fixtures.Orders {
+			userID, ok := users[f.User]
+			if !ok {
+				return fmt.Errorf("fixtures: order %q references unknown user %q", f.OrderNumber, f.User)
+			}
+
+			// Total is derived from the items, using the current product prices
+			total := 0.0
+			for _, item := range f.Items {
+				product, ok := products[item.Product]
+				if !ok {
+					return fmt.Errorf("fixtures: order %q references unknown product %q", f.OrderNumber, item.Product)
+				}
+				total += product.Price * float64(item.Quantity)
+			}
+
+			order := Order{}
+			err := upsert(tx, &order, Order{OrderNumber: f.OrderNumber},
+				Order{UserID: userID, Status: f.Status, Total: total})
+			if err != nil {
+				return fmt.Errorf("fixtures: order %q: %w", f.OrderNumber, err)
+			}
+
+			for _, item := range f.Items {
+				product := products[item.Product]
+				err := upsert(tx, &OrderItem{}, OrderItem{OrderID: order.ID, ProductID: product.ID},
+					OrderItem{Quantity: item.Quantity, Price: product.Price})
+				if err != nil {
+					return fmt.Errorf("fixtures: item %q of order %q: %w", item.Product, f.OrderNumber, err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// upsert loads the row matching where into dest, creating it when missing,
+// and applies assign when not nil. Soft-deleted rows are found too and
+// restored, so a deleted row is reused instead of colliding with its unique
+// key or being duplicated.
+func upsert(tx *gorm.DB, dest, where, assign interface{}) error {
+	query := tx.Unscoped().Where(where)
+	if assign != nil {
+		query = query.Assign(assign)
+	}
+	if err := query.FirstOrCreate(dest).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(dest).Where("deleted_at IS NOT NULL").Update("deleted_at", nil).Error
+}
+
+// ResetData permanently deletes every row of the tenant in db's context,
+// children before parents, so fixtures can be reloaded from scratch. Hooks
+// are skipped: the products whose ratings they would refresh go too.
+func ResetData(db *gorm.DB) error {
+	return db.Session(&gorm.Session{SkipHooks: true}).Transaction(func(tx *gorm.DB) error {
+		models := []interface{}{
+			&Review{},
+			&OrderItem{},
+			&Order{},
+			&UserLanguage{},
+			&CreditCard{},
+			&Profile{},
+			&User{},
+			&Language{},
+			&Product{},
+			&Category{},
+		}
+		for _, model := range models {
+			if err := tx.Unscoped().Where("1 = 1").Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
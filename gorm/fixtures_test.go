@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guilhermehermes/curso-go/gorm/tenant"
+	"gorm.io/gorm"
+)
+
+// countRows returns the number of rows of each model ResetData clears, soft-deleted
+// rows included, in db's tenant
+func countRows(t *testing.T, db *gorm.DB) map[string]int64 {
+	t.Helper()
+	models := map[string]interface{}{
+		"categories":     &Category{},
+		"products":       &Product{},
+		"languages":      &Language{},
+		"users":          &User{},
+		"profiles":       &Profile{},
+		"credit_cards":   &CreditCard{},
+		"user_languages": &UserLanguage{},
+		"orders":         &Order{},
+		"order_items":    &OrderItem{},
+		"reviews":        &Review{},
+	}
+	counts := map[string]int64{}
+	for name, model := range models {
+		var count int64
+		if err := db.Unscoped().Model(model).Count(&count).Error; err != nil {
+			t.Fatalf("count %s: %v", name, err)
+		}
+		counts[name] = count
+	}
+	return counts
+}
+
+func TestLoadFixturesIsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := LoadFixturesFile(db, "fixtures/dev.json"); err != nil {
+		t.Fatal(err)
+	}
+	loaded := countRows(t, db)
+	for name, count := range loaded {
+		if count == 0 && name != "reviews" {
+			t.Errorf("expected fixtures to create %s", name)
+		}
+	}
+
+	if err := LoadFixturesFile(db, "fixtures/dev.json"); err != nil {
+		t.Fatal(err)
+	}
+	for name, count := range countRows(t, db) {
+		if count != loaded[name] {
+			t.Errorf("reloading changed %s from %d to %d rows", name, loaded[name], count)
+		}
+	}
+}
+
+func TestLoadFixturesRestoresSoftDeletedRows(t *testing.T) {
+	db := openTestDB(t)
+	if err := LoadFixturesFile(db, "fixtures/dev.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	var product Product
+	if err := db.Where("name = ?", "Hoodie").First(&product).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&product).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(&Product{}, product.ID).Error; err == nil {
+		t.Fatal("expected the product to be soft-deleted")
+	}
+
+	if err := LoadFixturesFile(db, "fixtures/dev.json"); err != nil {
+		t.Fatal(err)
+	}
+	var restored []Product
+	db.Unscoped().Where("name = ?", "Hoodie").Find(&restored)
+	if len(restored) != 1 || restored[0].ID != product.ID || restored[0].DeletedAt.Valid {
+		t.Errorf("expected product %d restored in place, got %+v", product.ID, restored)
+	}
+}
+
+func TestResetDataClearsOnlyContextTenant(t *testing.T) {
+	db := openTestDB(t)
+	other := db.WithContext(tenant.WithTenant(context.Background(), 2))
+	for _, tx := range []*gorm.DB{db, other} {
+		if err := LoadFixturesFile(tx, "fixtures/dev.json"); err != nil {
+			t.Fatal(err)
+		}
+		var user User
+		var product Product
+		tx.First(&user)
+		tx.First(&product)
+		if _, err := CreateReview(tx, user.ID, product.ID, 4, "ok"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := countRows(t, other)
+
+	if err := ResetData(db); err != nil {
+		t.Fatal(err)
+	}
+	for name, count := range countRows(t, db) {
+		if count != 0 {
+			t.Errorf("expected reset to delete every %s of tenant 1, %d left", name, count)
+		}
+	}
+	for name, count := range countRows(t, other) {
+		if count != before[name] {
+			t.Errorf("reset changed tenant 2's %s from %d to %d rows", name, before[name], count)
+		}
+	}
+}
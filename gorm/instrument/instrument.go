@@ -0,0 +1,295 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startKey = "instrument:start"
+
+// Config controls what the Plugin reports
+type Config struct {
+	// SlowThreshold is the latency above which a query is logged with its
+	// rendered SQL. Zero disables slow query logging.
+	SlowThreshold time.Duration
+	// NPlusOneThreshold is how many times the same SQL may run within one
+	// tracked context before it is reported as an N+1 pattern. Zero disables
+	// the detection.
+	NPlusOneThreshold int
+	// Logger receives slow query and N+1 reports. Defaults to stderr.
+	Logger *log.Logger
+	// OnQuery, when set, is called after every statement
+	OnQuery func(QueryEvent)
+}
+
+// QueryEvent describes one executed statement
+type QueryEvent struct {
+	Operation    string
+	SQL          string
+	Duration     time.Duration
+	RowsAffected int64
+	Caller       string
+	Err          error
+}
+
+// Plugin records latency, rows affected and caller of every statement and
+// keeps counters that can be served by Handler
+type Plugin struct {
+	config Config
+
+	mu       sync.Mutex
+	queries  map[string]uint64
+	errors   uint64
+	slow     uint64
+	nPlusOne uint64
+	rows     int64
+	duration time.Duration
+}
+
+// New returns a Plugin using config
+func New(config Config) *Plugin {
+	if config.Logger == nil {
+		config.Logger = log.New(os.Stderr, "[gorm] ", log.LstdFlags)
+	}
+	return &Plugin{config: config, queries: map[string]uint64{}}
+}
+
+// Name implements gorm.Plugin
+func (p *Plugin) Name() string {
+	return "instrument"
+}
+
+// Initialize implements gorm.Plugin
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	type registerer interface {
+		Register(string, func(*gorm.DB)) error
+	}
+	hooks := []struct {
+		operation string
+		before    registerer
+		after     registerer
+	}{
+		{"create", cb.Create().Before("*"), cb.Create().After("*")},
+		{"query", cb.Query().Before("*"), cb.Query().After("*")},
+		{"update", cb.Update().Before("*"), cb.Update().After("*")},
+		{"delete", cb.Delete().Before("*"), cb.Delete().After("*")},
+		{"row", cb.Row().Before("*"), cb.Row().After("*")},
+		{"raw", cb.Raw().Before("*"), cb.Raw().After("*")},
+	}
+	for _, h := range hooks {
+		if err := h.before.Register("instrument:before_"+h.operation, p.before); err != nil {
+			return err
+		}
+		if err := h.after.Register("instrument:after_"+h.operation, p.after(h.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Plugin) before(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (p *Plugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, _ := value.(time.Time)
+
+		event := QueryEvent{
+			Operation:    operation,
+			SQL:          db.Statement.SQL.String(),
+			Duration:     time.Since(start),
+			RowsAffected: db.Statement.RowsAffected,
+			Caller:       caller(),
+		}
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			event.Err = db.Error
+		}
+		if event.SQL == "" {
+			// Rejected before any SQL was built, e.g. by another plugin:
+			// nothing ran, but the error still counts
+			if event.Err != nil {
+				p.mu.Lock()
+				p.errors++
+				p.mu.Unlock()
+			}
+			return
+		}
+
+		slow := p.config.SlowThreshold > 0 && event.Duration > p.config.SlowThreshold
+		if slow {
+			p.config.Logger.Printf("slow query (%v > %v) at %v, rows %v: %v",
+				event.Duration, p.config.SlowThreshold, event.Caller, event.RowsAffected,
+				db.Dialector.Explain(event.SQL, db.Statement.Vars...))
+		}
+
+		nPlusOne := false
+		if tracker := trackerFrom(db.Statement.Context); tracker != nil && p.config.NPlusOneThreshold > 0 {
+			if count := tracker.record(event.SQL); count == p.config.NPlusOneThreshold {
+				nPlusOne = true
+				p.config.Logger.Printf("possible N+1 at %v: same query ran %v times: %v",
+					event.Caller, count, event.SQL)
+			}
+		}
+
+		p.mu.Lock()
+		p.queries[operation]++
+		p.rows += event.RowsAffected
+		p.duration += event.Duration
+		if event.Err != nil {
+			p.errors++
+		}
+		if slow {
+			p.slow++
+		}
+		if nPlusOne {
+			p.nPlusOne++
+		}
+		p.mu.Unlock()
+
+		if p.config.OnQuery != nil {
+			p.config.OnQuery(event)
+		}
+	}
+}
+
+// caller returns the first frame outside GORM and this package
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "gorm.io/") && !strings.Contains(frame.Function, "/gorm/instrument.") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// Stats is a snapshot of the Plugin counters
+type Stats struct {
+	Queries       map[string]uint64
+	Errors        uint64
+	SlowQueries   uint64
+	NPlusOne      uint64
+	RowsAffected  int64
+	TotalDuration time.Duration
+}
+
+// Stats returns a snapshot of the counters
+func (p *Plugin) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	queries := make(map[string]uint64, len(p.queries))
+	for operation, count := range p.queries {
+		queries[operation] = count
+	}
+	return Stats{
+		Queries:       queries,
+		Errors:        p.errors,
+		SlowQueries:   p.slow,
+		NPlusOne:      p.nPlusOne,
+		RowsAffected:  p.rows,
+		TotalDuration: p.duration,
+	}
+}
+
+// WriteMetrics writes the counters in Prometheus text format
+func (p *Plugin) WriteMetrics(w io.Writer) error {
+	stats := p.Stats()
+
+	operations := make([]string, 0, len(stats.Queries))
+	for operation := range stats.Queries {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	var b strings.Builder
+	b.WriteString("# TYPE gorm_queries_total counter\n")
+	for _, operation := range operations {
+		fmt.Fprintf(&b, "gorm_queries_total{operation=%q} %d\n", operation, stats.Queries[operation])
+	}
+	fmt.Fprintf(&b, "# TYPE gorm_query_errors_total counter\ngorm_query_errors_total %d\n", stats.Errors)
+	fmt.Fprintf(&b, "# TYPE gorm_slow_queries_total counter\ngorm_slow_queries_total %d\n", stats.SlowQueries)
+	fmt.Fprintf(&b, "# TYPE gorm_n_plus_one_total counter\ngorm_n_plus_one_total %d\n", stats.NPlusOne)
+	fmt.Fprintf(&b, "# TYPE gorm_rows_affected_total counter\ngorm_rows_affected_total %d\n", stats.RowsAffected)
+	fmt.Fprintf(&b, "# TYPE gorm_query_duration_seconds_total counter\ngorm_query_duration_seconds_total %g\n", stats.TotalDuration.Seconds())
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the counters for a metrics endpoint
+func (p *Plugin) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		p.WriteMetrics(w)
+	})
+}
+
+// Tracker counts how often each SQL statement runs within one context,
+// typically a single HTTP request, to spot N+1 query patterns
+type Tracker struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+type trackerKey struct{}
+
+// WithTracker returns a copy of ctx carrying a new Tracker
+func WithTracker(ctx context.Context) (context.Context, *Tracker) {
+	tracker := &Tracker{counts: map[string]int{}}
+	return context.WithValue(ctx, trackerKey{}, tracker), tracker
+}
+
+func trackerFrom(ctx context.Context) *Tracker {
+	if ctx == nil {
+		return nil
+	}
+	tracker, _ := ctx.Value(trackerKey{}).(*Tracker)
+	return tracker
+}
+
+func (t *Tracker) record(sql string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counts[sql]++
+	return t.counts[sql]
+}
+
+// Repeated returns the statements that ran at least min times
+func (t *Tracker) Repeated(min int) map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	repeated := map[string]int{}
+	for sql, count := range t.counts {
+		if count >= min {
+			repeated[sql] = count
+		}
+	}
+	return repeated
+}
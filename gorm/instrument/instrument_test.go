@@ -0,0 +1,146 @@
+package instrument_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/guilhermehermes/curso-go/gorm/instrument"
+	"github.com/guilhermehermes/curso-go/gorm/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type item struct {
+	ID   uint
+	Name string
+}
+
+type note struct {
+	ID       uint
+	TenantID uint
+	Text     string
+}
+
+// openDB returns an in-memory SQLite database with the test tables migrated
+// before the plugin is installed, so migrations are not counted
+func openDB(t *testing.T, config instrument.Config) (*gorm.DB, *instrument.Plugin, *bytes.Buffer) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&item{}, &note{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	config.Logger = log.New(&logs, "", 0)
+	plugin := instrument.New(config)
+	if err := db.Use(plugin); err != nil {
+		t.Fatal(err)
+	}
+	return db, plugin, &logs
+}
+
+func TestCountersAndMetrics(t *testing.T) {
+	db, plugin, _ := openDB(t, instrument.Config{})
+
+	db.Create(&[]item{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	db.Find(&[]item{})
+	db.Model(&item{}).Where("name <> ?", "a").Update("name", "x")
+	db.First(&item{}, 99) // not found is not an error
+
+	stats := plugin.Stats()
+	if stats.Queries["create"] != 1 || stats.Queries["query"] != 2 || stats.Queries["update"] != 1 {
+		t.Errorf("unexpected query counts %v", stats.Queries)
+	}
+	if stats.RowsAffected != 3+3+2 || stats.Errors != 0 || stats.TotalDuration <= 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	var metrics strings.Builder
+	if err := plugin.WriteMetrics(&metrics); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE gorm_queries_total counter\n",
+		`gorm_queries_total{operation="create"} 1` + "\n",
+		`gorm_queries_total{operation="query"} 2` + "\n",
+		"gorm_query_errors_total 0\n",
+		"gorm_rows_affected_total 8\n",
+	} {
+		if !strings.Contains(metrics.String(), line) {
+			t.Errorf("expected %q in metrics:\n%s", line, metrics.String())
+		}
+	}
+}
+
+func TestCountsErrorsRejectedBeforeSQL(t *testing.T) {
+	db, plugin, _ := openDB(t, instrument.Config{})
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Find(&[]note{}).Error; !errors.Is(err, tenant.ErrMissingTenant) {
+		t.Fatalf("expected ErrMissingTenant, got %v", err)
+	}
+	db.Exec("SELECT * FROM missing_table")
+
+	if stats := plugin.Stats(); stats.Errors != 2 {
+		t.Errorf("expected both errors counted, got %+v", stats)
+	}
+}
+
+func TestSlowQueryAndCaller(t *testing.T) {
+	var events []instrument.QueryEvent
+	db, plugin, logs := openDB(t, instrument.Config{
+		SlowThreshold: time.Nanosecond,
+		OnQuery:       func(event instrument.QueryEvent) { events = append(events, event) },
+	})
+
+	db.Where("name = ?", "a").Find(&[]item{})
+
+	if plugin.Stats().SlowQueries != 1 {
+		t.Errorf("expected 1 slow query, got %d", plugin.Stats().SlowQueries)
+	}
+	// The log has the SQL with its parameters rendered
+	if !strings.Contains(logs.String(), "slow query") || !strings.Contains(logs.String(), `name = "a"`) {
+		t.Errorf("unexpected log %q", logs.String())
+	}
+	if len(events) != 1 || !strings.Contains(events[0].Caller, "instrument_test.go:") {
+		t.Errorf("expected the caller to be this test, got %+v", events)
+	}
+}
+
+func TestNPlusOne(t *testing.T) {
+	db, plugin, logs := openDB(t, instrument.Config{NPlusOneThreshold: 3})
+	db.Create(&item{Name: "a"})
+
+	ctx, tracker := instrument.WithTracker(context.Background())
+	for i := 0; i < 5; i++ {
+		db.WithContext(ctx).Where("id = ?", i).Find(&[]item{})
+	}
+	// Statements outside a tracked context are never reported
+	for i := 0; i < 5; i++ {
+		db.Where("id = ?", i).Find(&[]item{})
+	}
+
+	if plugin.Stats().NPlusOne != 1 || strings.Count(logs.String(), "possible N+1") != 1 {
+		t.Errorf("expected one N+1 report at the threshold, got %d: %q", plugin.Stats().NPlusOne, logs.String())
+	}
+	repeated := tracker.Repeated(3)
+	if len(repeated) != 1 {
+		t.Fatalf("expected one repeated statement, got %v", repeated)
+	}
+	for sql, count := range repeated {
+		if count != 5 || !strings.Contains(sql, "SELECT") {
+			t.Errorf("expected the select to repeat 5 times, got %q %d", sql, count)
+		}
+	}
+}
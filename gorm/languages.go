@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProficiencyLevel is how well a User knows a Language, ordered from
+// beginner to native so levels can be compared in queries
+type ProficiencyLevel int
+
+const (
+	Beginner ProficiencyLevel = iota + 1
+	Elementary
+	Intermediate
+	Advanced
+	Native
+)
+
+var proficiencyNames = map[ProficiencyLevel]string{
+	Beginner:     "beginner",
+	Elementary:   "elementary",
+	Intermediate: "intermediate",
+	Advanced:     "advanced",
+	Native:       "native",
+}
+
+func (l ProficiencyLevel) String() string {
+	if name, ok := proficiencyNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("ProficiencyLevel(%d)", int(l))
+}
+
+// ParseProficiencyLevel converts a name such as "advanced" into a ProficiencyLevel
+func ParseProficiencyLevel(name string) (ProficiencyLevel, error) {
+	for level, levelName := range proficiencyNames {
+		if strings.EqualFold(strings.TrimSpace(name), levelName) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown proficiency level %q", name)
+}
+
+// UserLanguage is the user_languages join table between User and Language,
+// carrying how well the user knows the language
+type UserLanguage struct {
+	UserID            uint `gorm:"primaryKey"`
+	LanguageID        uint `gorm:"primaryKey"`
+	TenantID          uint `gorm:"index"`
+	Level             ProficiencyLevel
+	YearsOfExperience int
+	CertifiedAt       *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Language          Language // UserLanguage belongs to Language
+}
+
+// Proficiency holds the attributes that can be set on a UserLanguage
+type Proficiency struct {
+	Level             ProficiencyLevel
+	YearsOfExperience int
+	CertifiedAt       *time.Time
+}
+
+// setupJoinTables replaces GORM's generated user_languages table with UserLanguage
+// on both sides of the relationship. It must run before AutoMigrate.
+func setupJoinTables(db *gorm.DB) error {
+	if err := db.SetupJoinTable(&User{}, "Languages", &UserLanguage{}); err != nil {
+		return err
+	}
+	return db.SetupJoinTable(&Language{}, "Users", &UserLanguage{})
+}
+
+// SetLanguageProficiency associates the user with the language, creating
+// the link if needed, and stores the given proficiency on it. The user and
+// the language must belong to the context tenant, otherwise it returns
+// gorm.ErrRecordNotFound.
+func SetLanguageProficiency(db *gorm.DB, userID, languageID uint, p Proficiency) error {
+	// Both lookups go through the tenant scope
+	if err := db.Select("id").First(&User{}, userID).Error; err != nil {
+		return err
+	}
+	if err := db.Select("id").First(&Language{}, languageID).Error; err != nil {
+		return err
+	}
+
+	link := UserLanguage{
+		UserID:            userID,
+		LanguageID:        languageID,
+		Level:             p.Level,
+		YearsOfExperience: p.YearsOfExperience,
+		CertifiedAt:       p.CertifiedAt,
+	}
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "language_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"level", "years_of_experience", "certified_at", "updated_at"}),
+		// Never overwrite a link of another tenant
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "user_languages.tenant_id = excluded.tenant_id"},
+		}},
+	}).Create(&link)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// GetLanguageProficiency returns the link between a user and a language
+func GetLanguageProficiency(db *gorm.DB, userID, languageID uint) (UserLanguage, error) {
+	var link UserLanguage
+	err := db.Preload("Language").
+		Where("user_id = ? AND language_id = ?", userID, languageID).
+		First(&link).Error
+	return link, err
+}
+
+// ListUserLanguages returns every language the user knows, most proficient first
+func ListUserLanguages(db *gorm.DB, userID uint) ([]UserLanguage, error) {
+	var links []UserLanguage
+	err := db.Preload("Language").
+		Where("user_id = ?", userID).
+		Order("level DESC").Order("years_of_experience DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// FindUsersByLanguage returns users who know the named language at minLevel or above
+func FindUsersByLanguage(db *gorm.DB, languageName string, minLevel ProficiencyLevel) ([]User, error) {
+	var users []User
+	err := db.
+		Joins("JOIN user_languages ON user_languages.user_id = users.id").
+		Joins("JOIN languages ON languages.id = user_languages.language_id").
+		Where("languages.name = ? AND user_languages.level >= ?", languageName, minLevel).
+		Distinct().
+		Find(&users).Error
+	return users, err
+}
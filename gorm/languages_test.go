@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/guilhermehermes/curso-go/gorm/tenant"
+	"gorm.io/gorm"
+)
+
+func TestLanguageProficiency(t *testing.T) {
+	db := openTestDB(t)
+
+	users := []User{{Name: "Ana", Email: "ana@example.com"}, {Name: "Bruno", Email: "bruno@example.com"}}
+	db.Create(&users)
+	languages := []Language{{Name: "Go"}, {Name: "Rust"}}
+	db.Create(&languages)
+	goLang, rust := languages[0].ID, languages[1].ID
+
+	set := func(userID, languageID uint, p Proficiency) {
+		t.Helper()
+		if err := SetLanguageProficiency(db, userID, languageID, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set(users[0].ID, goLang, Proficiency{Level: Intermediate, YearsOfExperience: 2})
+	set(users[0].ID, rust, Proficiency{Level: Beginner})
+	set(users[1].ID, goLang, Proficiency{Level: Elementary, YearsOfExperience: 1})
+	// Setting it again updates the existing link
+	set(users[0].ID, goLang, Proficiency{Level: Advanced, YearsOfExperience: 4})
+
+	link, err := GetLanguageProficiency(db, users[0].ID, goLang)
+	if err != nil || link.Level != Advanced || link.YearsOfExperience != 4 || link.Language.Name != "Go" {
+		t.Errorf("get: expected advanced Go for 4 years, got %+v (%v)", link, err)
+	}
+
+	links, err := ListUserLanguages(db, users[0].ID)
+	if err != nil || len(links) != 2 || links[0].Language.Name != "Go" || links[1].Language.Name != "Rust" {
+		t.Errorf("list: expected Go then Rust, got %+v (%v)", links, err)
+	}
+
+	for level, expected := range map[ProficiencyLevel]int{Beginner: 2, Elementary: 2, Intermediate: 1, Native: 0} {
+		found, err := FindUsersByLanguage(db, "Go", level)
+		if err != nil || len(found) != expected {
+			t.Errorf("find Go at %s: expected %d users, got %d (%v)", level, expected, len(found), err)
+		}
+	}
+}
+
+func TestLanguageProficiencyIsTenantScoped(t *testing.T) {
+	db := openTestDB(t)
+	other := db.WithContext(tenant.WithTenant(context.Background(), 2))
+
+	user := User{Email: "other@example.com"}
+	other.Create(&user)
+	language := Language{Name: "Go"}
+	other.Create(&language)
+	if err := SetLanguageProficiency(other, user.ID, language.ID, Proficiency{Level: Beginner}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := SetLanguageProficiency(db, user.ID, language.ID, Proficiency{Level: Native})
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound for another tenant's user and language, got %v", err)
+	}
+	link, err := GetLanguageProficiency(other, user.ID, language.ID)
+	if err != nil || link.Level != Beginner {
+		t.Errorf("expected the other tenant's link to stay beginner, got %s (%v)", link.Level, err)
+	}
+}
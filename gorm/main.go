@@ -1,19 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"time"
 
+	"github.com/guilhermehermes/curso-go/gorm/instrument"
+	"github.com/guilhermehermes/curso-go/gorm/tenant"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// Tenant is a store hosted on the platform; every other model is scoped to one
+type Tenant struct {
+	gorm.Model
+	Name string `gorm:"uniqueIndex"`
+}
+
 // User has many CreditCards, UserID is the foreign key
 type User struct {
 	gorm.Model
+	TenantID   uint `gorm:"uniqueIndex:idx_users_tenant_email"`
 	Name       string
-	Email      string `gorm:"uniqueIndex"`
+	Email      string `gorm:"uniqueIndex:idx_users_tenant_email"`
 	Age        uint8
 	Profile    Profile      // User has one Profile
 	CreditCard []CreditCard // User has many CreditCards
@@ -24,6 +37,7 @@ type User struct {
 // Profile belongs to User, UserID is the foreign key
 type Profile struct {
 	gorm.Model
+	TenantID    uint `gorm:"index"`
 	UserID      uint
 	Bio         string
 	PhoneNumber string
@@ -33,20 +47,23 @@ type Profile struct {
 // CreditCard belongs to User, UserID is the foreign key
 type CreditCard struct {
 	gorm.Model
-	Number string
-	UserID uint
+	TenantID uint `gorm:"index"`
+	Number   string
+	UserID   uint
 }
 
 // Language belongs to many Users
 type Language struct {
 	gorm.Model
-	Name  string
-	Users []User `gorm:"many2many:user_languages;"`
+	TenantID uint `gorm:"index"`
+	Name     string
+	Users    []User `gorm:"many2many:user_languages;"`
 }
 
 // Order belongs to User
 type Order struct {
 	gorm.Model
+	TenantID    uint `gorm:"index"`
 	UserID      uint
 	OrderNumber string
 	Total       float64
@@ -57,6 +74,7 @@ type Order struct {
 // OrderItem belongs to Order
 type OrderItem struct {
 	gorm.Model
+	TenantID  uint `gorm:"index"`
 	OrderID   uint
 	ProductID uint
 	Quantity  int
@@ -67,22 +85,32 @@ type OrderItem struct {
 // Product belongs to Category
 type Product struct {
 	gorm.Model
-	Name        string
-	Description string
-	Price       float64
-	CategoryID  uint
-	Category    Category
+	TenantID      uint `gorm:"index"`
+	Name          string
+	Description   string
+	Price         float64
+	CategoryID    uint
+	Category      Category
+	AverageRating float64  // Cached from approved Reviews
+	ReviewCount   int      // Cached from approved Reviews
+	Reviews       []Review // Product has many Reviews
 }
 
 // Category has many Products
 type Category struct {
 	gorm.Model
+	TenantID    uint `gorm:"index"`
 	Name        string
 	Description string
 	Products    []Product
 }
 
 func main() {
+	seed := flag.String("seed", "", "load fixtures from the given JSON file instead of running the demos")
+	reset := flag.Bool("reset", false, "delete all rows of the tenant before anything else")
+	metricsAddr := flag.String("metrics", "", "serve query metrics on this address after the demos, e.g. :9090")
+	flag.Parse()
+
 	// Connect to database
 	dsn := "host=localhost user=postgres password=postgres dbname=testdb port=5433 sslmode=disable TimeZone=UTC"
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -90,21 +118,68 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	// Scope every tenant-aware model to the tenant carried in the context
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		log.Fatalf("Failed to register tenant plugin: %v", err)
+	}
+
+	// Record latency of every query, log slow ones and repeated ones (N+1)
+	queries := instrument.New(instrument.Config{
+		SlowThreshold:     200 * time.Millisecond,
+		NPlusOneThreshold: 5,
+	})
+	if err := db.Use(queries); err != nil {
+		log.Fatalf("Failed to register instrumentation plugin: %v", err)
+	}
+
+	// Use UserLanguage as the user_languages join table
+	if err := setupJoinTables(db); err != nil {
+		log.Fatalf("Failed to set up join tables: %v", err)
+	}
+
 	// Auto migrate schemas
 	err = db.AutoMigrate(
+		&Tenant{},
 		&User{},
 		&Profile{},
 		&CreditCard{},
 		&Language{},
+		&UserLanguage{},
 		&Category{},  // Migrate Category first
 		&Product{},   // Then Product which depends on Category
 		&Order{},     // Then Order
-		&OrderItem{}, // Finally OrderItem which depends on Product and Order
+		&OrderItem{}, // Then OrderItem which depends on Product and Order
+		&Review{},    // Finally Review which depends on User and Product
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	// Every query below runs on behalf of a single store
+	var store Tenant
+	if err := db.Where(Tenant{Name: "default"}).FirstOrCreate(&store).Error; err != nil {
+		log.Fatalf("Failed to load tenant: %v", err)
+	}
+	ctx, tracker := instrument.WithTracker(tenant.WithTenant(context.Background(), store.ID))
+	db = db.WithContext(ctx)
+
+	// Fixture mode: go run . -reset -seed fixtures/dev.json
+	if *reset || *seed != "" {
+		if *reset {
+			if err := ResetData(db); err != nil {
+				log.Fatalf("Failed to reset data: %v", err)
+			}
+			fmt.Println("Tenant data reset")
+		}
+		if *seed != "" {
+			if err := LoadFixturesFile(db, *seed); err != nil {
+				log.Fatalf("Failed to load fixtures: %v", err)
+			}
+			fmt.Printf("Fixtures loaded from %v\n", *seed)
+		}
+		return
+	}
+
 	// Demonstration of different operations and relationships
 	demonstrateCRUD(db)
 	demonstrateHasOne(db)
@@ -112,18 +187,27 @@ func main() {
 	demonstrateBelongsTo(db)
 	demonstrateManyToMany(db)
 	demonstrateComplexRelationships(db)
+	demonstrateReviews(db)
+
+	fmt.Printf("\n%v statements repeated 5 or more times\n", len(tracker.Repeated(5)))
+	stats := queries.Stats()
+	fmt.Printf("Ran %v queries in %v (%v slow, %v errors)\n", stats.Queries["query"], stats.TotalDuration, stats.SlowQueries, stats.Errors)
+
+	if *metricsAddr != "" {
+		log.Printf("Serving query metrics on %v/metrics", *metricsAddr)
+		http.Handle("/metrics", queries.Handler())
+		log.Fatal(http.ListenAndServe(*metricsAddr, nil))
+	}
 }
 
 func demonstrateCRUD(db *gorm.DB) {
 	fmt.Println("\n=== CRUD Operations ===")
 
-	// Create
-	user := User{
-		Name:  "John Doe",
-		Email: "john@example.com",
-		Age:   30,
-	}
-	result := db.Create(&user)
+	// Create, or reuse the user from a previous run: email is unique
+	user := User{}
+	result := db.Where(User{Email: "john@example.com"}).
+		Attrs(User{Name: "John Doe", Age: 30}).
+		FirstOrCreate(&user)
 	if result.Error != nil {
 		log.Printf("Error creating user: %v", result.Error)
 	} else {
@@ -156,21 +240,14 @@ func demonstrateHasOne(db *gorm.DB) {
 	fmt.Println("\n=== Has One Relationship ===")
 
 	// Create a user
-	user := User{
-		Name:  "Alice",
-		Email: "alice@example.com",
-		Age:   25,
-	}
-	db.Create(&user)
+	user := User{}
+	db.Where(User{Email: "alice@example.com"}).Attrs(User{Name: "Alice", Age: 25}).FirstOrCreate(&user)
 
 	// Create a profile for the user (HasOne relationship)
-	profile := Profile{
-		UserID:      user.ID,
-		Bio:         "Software developer",
-		PhoneNumber: "123-456-7890",
-		Address:     "123 Main St",
-	}
-	db.Create(&profile)
+	profile := Profile{}
+	db.Where(Profile{UserID: user.ID}).
+		Attrs(Profile{Bio: "Software developer", PhoneNumber: "123-456-7890", Address: "123 Main St"}).
+		FirstOrCreate(&profile)
 
 	// Retrieve user with profile
 	var userWithProfile User
@@ -182,12 +259,8 @@ func demonstrateHasMany(db *gorm.DB) {
 	fmt.Println("\n=== Has Many Relationship ===")
 
 	// Create a user
-	user := User{
-		Name:  "Bob",
-		Email: "bob@example.com",
-		Age:   35,
-	}
-	db.Create(&user)
+	user := User{}
+	db.Where(User{Email: "bob@example.com"}).Attrs(User{Name: "Bob", Age: 35}).FirstOrCreate(&user)
 
 	// Create credit cards for the user (HasMany relationship)
 	creditCards := []CreditCard{
@@ -195,7 +268,7 @@ func demonstrateHasMany(db *gorm.DB) {
 		{Number: "5555-6666-7777-8888", UserID: user.ID},
 	}
 	for _, card := range creditCards {
-		db.Create(&card)
+		db.Where(card).FirstOrCreate(&CreditCard{})
 	}
 
 	// Retrieve user with credit cards
@@ -210,21 +283,17 @@ func demonstrateHasMany(db *gorm.DB) {
 func demonstrateBelongsTo(db *gorm.DB) {
 	fmt.Println("\n=== Belongs To Relationship ===")
 
-	// Create a category
-	category := Category{
-		Name:        "Electronics",
-		Description: "Electronic devices and gadgets",
-	}
-	db.Create(&category)
+	// Create a category, once: names identify categories across runs
+	category := Category{}
+	db.Where(Category{Name: "Electronics"}).
+		Attrs(Category{Description: "Electronic devices and gadgets"}).
+		FirstOrCreate(&category)
 
 	// Create a product that belongs to the category
-	product := Product{
-		Name:        "Smartphone",
-		Description: "Latest model",
-		Price:       999.99,
-		CategoryID:  category.ID,
-	}
-	db.Create(&product)
+	product := Product{}
+	db.Where(Product{Name: "Smartphone"}).
+		Attrs(Product{Description: "Latest model", Price: 999.99, CategoryID: category.ID}).
+		FirstOrCreate(&product)
 
 	// Retrieve product with its category
 	var retrievedProduct Product
@@ -236,12 +305,8 @@ func demonstrateManyToMany(db *gorm.DB) {
 	fmt.Println("\n=== Many to Many Relationship ===")
 
 	// Create a user
-	user := User{
-		Name:  "Charlie",
-		Email: "charlie@example.com",
-		Age:   28,
-	}
-	db.Create(&user)
+	user := User{}
+	db.Where(User{Email: "charlie@example.com"}).Attrs(User{Name: "Charlie", Age: 28}).FirstOrCreate(&user)
 
 	// Create languages
 	languages := []Language{
@@ -250,7 +315,7 @@ func demonstrateManyToMany(db *gorm.DB) {
 		{Name: "JavaScript"},
 	}
 	for i := range languages {
-		db.Create(&languages[i])
+		db.Where(Language{Name: languages[i].Name}).FirstOrCreate(&languages[i])
 	}
 
 	// Associate languages with user
@@ -268,18 +333,34 @@ func demonstrateManyToMany(db *gorm.DB) {
 	var goLang Language
 	db.Preload("Users").Where("name = ?", "Go").First(&goLang)
 	fmt.Printf("Language: %v is known by %v users\n", goLang.Name, len(goLang.Users))
+
+	// Store how well the user knows each language on the join table
+	certifiedAt := time.Now()
+	SetLanguageProficiency(db, user.ID, languages[0].ID, Proficiency{Level: Advanced, YearsOfExperience: 5, CertifiedAt: &certifiedAt})
+	SetLanguageProficiency(db, user.ID, languages[1].ID, Proficiency{Level: Intermediate, YearsOfExperience: 2})
+
+	links, err := ListUserLanguages(db, user.ID)
+	if err != nil {
+		log.Printf("Error listing user languages: %v", err)
+	}
+	for _, link := range links {
+		fmt.Printf("  %v: %v, %v years\n", link.Language.Name, link.Level, link.YearsOfExperience)
+	}
+
+	// Search users by language and minimum proficiency
+	experts, err := FindUsersByLanguage(db, "Go", Advanced)
+	if err != nil {
+		log.Printf("Error searching users by language: %v", err)
+	}
+	fmt.Printf("%v users know Go at level %v or above\n", len(experts), Advanced)
 }
 
 func demonstrateComplexRelationships(db *gorm.DB) {
 	fmt.Println("\n=== Complex Relationships ===")
 
 	// Create a user
-	user := User{
-		Name:  "David",
-		Email: "david@example.com",
-		Age:   40,
-	}
-	db.Create(&user)
+	user := User{}
+	db.Where(User{Email: "david@example.com"}).Attrs(User{Name: "David", Age: 40}).FirstOrCreate(&user)
 
 	// Create categories
 	categories := []Category{
@@ -287,7 +368,7 @@ func demonstrateComplexRelationships(db *gorm.DB) {
 		{Name: "Clothing", Description: "Apparel and accessories"},
 	}
 	for i := range categories {
-		db.Create(&categories[i])
+		db.Where(Category{Name: categories[i].Name}).Attrs(categories[i]).FirstOrCreate(&categories[i])
 	}
 
 	// Create products
@@ -297,17 +378,14 @@ func demonstrateComplexRelationships(db *gorm.DB) {
 		{Name: "Hoodie", Description: "Warm hoodie", Price: 39.99, CategoryID: categories[1].ID},
 	}
 	for i := range products {
-		db.Create(&products[i])
+		db.Where(Product{Name: products[i].Name}).Attrs(products[i]).FirstOrCreate(&products[i])
 	}
 
-	// Create an order with items
-	order := Order{
-		UserID:      user.ID,
-		OrderNumber: fmt.Sprintf("ORD-%v", time.Now().Unix()),
-		Total:       109.97,
-		Status:      "pending",
-	}
-	db.Create(&order)
+	// Create an order with items; a fixed order number keeps reruns from adding orders
+	order := Order{}
+	db.Where(Order{OrderNumber: "ORD-DEMO-DAVID"}).
+		Attrs(Order{UserID: user.ID, Total: 109.97, Status: "pending"}).
+		FirstOrCreate(&order)
 
 	// Create order items
 	orderItems := []OrderItem{
@@ -316,7 +394,9 @@ func demonstrateComplexRelationships(db *gorm.DB) {
 		{OrderID: order.ID, ProductID: products[2].ID, Quantity: 1, Price: products[2].Price},
 	}
 	for i := range orderItems {
-		db.Create(&orderItems[i])
+		db.Where(OrderItem{OrderID: order.ID, ProductID: orderItems[i].ProductID}).
+			Attrs(orderItems[i]).
+			FirstOrCreate(&orderItems[i])
 	}
 
 	// Retrieve the order with all its details
@@ -340,3 +420,46 @@ func demonstrateComplexRelationships(db *gorm.DB) {
 	db.Preload("Orders.Items.Product.Category").First(&userWithOrders, user.ID)
 	fmt.Printf("User: %v has %v orders\n", userWithOrders.Name, len(userWithOrders.Orders))
 }
+
+func demonstrateReviews(db *gorm.DB) {
+	fmt.Println("\n=== Reviews and Ratings ===")
+
+	// Deliver one of David's orders so his review counts as a verified purchase
+	var order Order
+	db.Preload("Items").Joins("JOIN users ON users.id = orders.user_id").
+		Where("users.email = ?", "david@example.com").Last(&order)
+	db.Model(&order).Update("status", OrderDelivered)
+	if len(order.Items) == 0 {
+		fmt.Println("No order items to review")
+		return
+	}
+
+	// A user reviews a product only once, so reuse the review from a previous run
+	var review Review
+	err := db.Where(Review{UserID: order.UserID, ProductID: order.Items[0].ProductID}).First(&review).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		review, err = CreateReview(db, order.UserID, order.Items[0].ProductID, 5, "Great book!")
+	}
+	if err != nil {
+		log.Printf("Error creating review: %v", err)
+		return
+	}
+	fmt.Printf("Review %v is %v, verified purchase: %v\n", review.ID, review.Status, review.VerifiedPurchase)
+
+	// Ratings only count once the review is approved
+	ModerateReview(db, review.ID, ReviewApproved)
+
+	var product Product
+	db.First(&product, review.ProductID)
+	fmt.Printf("Product: %v has rating %.1f from %v reviews\n", product.Name, product.AverageRating, product.ReviewCount)
+
+	top, err := TopRatedProductsByCategory(db, 3, 1)
+	if err != nil {
+		log.Printf("Error fetching top rated products: %v", err)
+	}
+	for categoryID, products := range top {
+		for i, p := range products {
+			fmt.Printf("  Category %v #%d: %v (%.1f)\n", categoryID, i+1, p.Name, p.AverageRating)
+		}
+	}
+}
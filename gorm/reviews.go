@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// OrderDelivered is the Order status that makes a review a verified purchase
+const OrderDelivered = "delivered"
+
+// ReviewStatus is the moderation state of a Review
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+// ErrInvalidRating is returned when a review rating is outside 1..5
+var ErrInvalidRating = errors.New("rating must be between 1 and 5")
+
+// ErrUnknownReviewProduct is returned when a deleted review's product cannot
+// be determined, so its cached rating cannot be refreshed
+var ErrUnknownReviewProduct = errors.New("review: product of deleted review unknown")
+
+// Review belongs to User and Product; a user reviews a product at most once
+type Review struct {
+	gorm.Model
+	TenantID         uint `gorm:"index"`
+	UserID           uint `gorm:"uniqueIndex:idx_reviews_user_product"`
+	ProductID        uint `gorm:"uniqueIndex:idx_reviews_user_product"`
+	Rating           int
+	Text             string
+	VerifiedPurchase bool
+	Status           ReviewStatus `gorm:"index"`
+	User             User
+	Product          Product
+}
+
+// BeforeSave validates the rating
+func (r *Review) BeforeSave(tx *gorm.DB) error {
+	if r.Rating != 0 && (r.Rating < 1 || r.Rating > 5) {
+		return ErrInvalidRating
+	}
+	return nil
+}
+
+// BeforeCreate starts the review as pending and derives VerifiedPurchase
+// from the user's delivered orders
+func (r *Review) BeforeCreate(tx *gorm.DB) error {
+	if r.Rating == 0 {
+		return ErrInvalidRating
+	}
+	if r.Status == "" {
+		r.Status = ReviewPending
+	}
+
+	var count int64
+	err := tx.Model(&OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND orders.status = ? AND order_items.product_id = ?", r.UserID, OrderDelivered, r.ProductID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	r.VerifiedPurchase = count > 0
+	return nil
+}
+
+// AfterSave keeps the product's cached rating in sync
+func (r *Review) AfterSave(tx *gorm.DB) error {
+	return RefreshProductRating(tx, r.ProductID)
+}
+
+// reviewProductsKey holds the products of the reviews a delete matches,
+// from BeforeDelete to AfterDelete
+const reviewProductsKey = "reviews:product_ids"
+
+// BeforeDelete finds the products of the reviews about to be deleted when
+// the caller did not pass one, as in db.Delete(&Review{}, id) or
+// db.Where("user_id = ?", id).Delete(&Review{})
+func (r *Review) BeforeDelete(tx *gorm.DB) error {
+	if r.ProductID != 0 {
+		return nil
+	}
+
+	query := tx.Model(&Review{}).Distinct()
+	if tx.Statement.Unscoped {
+		query = query.Unscoped()
+	}
+	if where, ok := tx.Statement.Clauses["WHERE"]; ok {
+		query = query.Clauses(where.Expression)
+	}
+	if r.ID != 0 {
+		query = query.Where("id = ?", r.ID)
+	}
+
+	var productIDs []uint
+	if err := query.Pluck("product_id", &productIDs).Error; err != nil {
+		return err
+	}
+	tx.Statement.Settings.Store(reviewProductsKey, productIDs)
+	return nil
+}
+
+// AfterDelete keeps the cached rating of every affected product in sync.
+// Deleting with conditions that match no review is rejected, because the
+// product to refresh is then unknown.
+func (r *Review) AfterDelete(tx *gorm.DB) error {
+	if r.ProductID != 0 {
+		return RefreshProductRating(tx, r.ProductID)
+	}
+
+	productIDs, _ := tx.Statement.Settings.Load(reviewProductsKey)
+	ids, _ := productIDs.([]uint)
+	if len(ids) == 0 {
+		return ErrUnknownReviewProduct
+	}
+	for _, productID := range ids {
+		if err := RefreshProductRating(tx, productID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateReview stores a pending review of the product by the user
+func CreateReview(db *gorm.DB, userID, productID uint, rating int, text string) (Review, error) {
+	review := Review{
+		UserID:    userID,
+		ProductID: productID,
+		Rating:    rating,
+		Text:      text,
+	}
+	err := db.Create(&review).Error
+	return review, err
+}
+
+// ModerateReview moves a review to the given status; only approved reviews
+// count towards the product rating
+func ModerateReview(db *gorm.DB, reviewID uint, status ReviewStatus) error {
+	var review Review
+	if err := db.First(&review, reviewID).Error; err != nil {
+		return err
+	}
+	return db.Model(&review).Update("status", status).Error
+}
+
+// RefreshProductRating recomputes the cached AverageRating and ReviewCount
+// of a product from its approved reviews
+func RefreshProductRating(db *gorm.DB, productID uint) error {
+	if productID == 0 {
+		return nil
+	}
+
+	var stats struct {
+		Average float64
+		Count   int
+	}
+	err := db.Model(&Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("product_id = ? AND status = ?", productID, ReviewApproved).
+		Scan(&stats).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Model(&Product{}).Where("id = ?", productID).
+		Updates(map[string]interface{}{"average_rating": stats.Average, "review_count": stats.Count}).Error
+}
+
+// TopRatedProductsByCategory returns, for each category, up to limit products
+// with at least minReviews approved reviews, best rated first
+func TopRatedProductsByCategory(db *gorm.DB, limit, minReviews int) (map[uint][]Product, error) {
+	ranked := db.Model(&Product{}).
+		Select("products.*, ROW_NUMBER() OVER (PARTITION BY category_id ORDER BY average_rating DESC, review_count DESC) AS rating_rank").
+		Where("review_count >= ?", minReviews)
+
+	var products []Product
+	err := db.Table("(?) AS products", ranked).
+		Where("rating_rank <= ?", limit).
+		Order("category_id").Order("rating_rank").
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	top := map[uint][]Product{}
+	for _, product := range products {
+		top[product.CategoryID] = append(top[product.CategoryID], product)
+	}
+	return top, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/guilhermehermes/curso-go/gorm/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB returns an in-memory SQLite database with the schema migrated
+// and every statement scoped to tenant 1
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := setupJoinTables(db); err != nil {
+		t.Fatal(err)
+	}
+	err = db.AutoMigrate(&User{}, &Profile{}, &CreditCard{}, &Language{}, &UserLanguage{},
+		&Category{}, &Product{}, &Order{}, &OrderItem{}, &Review{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.WithContext(tenant.WithTenant(context.Background(), 1))
+}
+
+func TestProductRatingCache(t *testing.T) {
+	db := openTestDB(t)
+
+	product := Product{Name: "Hoodie", Price: 39.99}
+	db.Create(&product)
+	users := []User{{Email: "a@example.com"}, {Email: "b@example.com"}}
+	db.Create(&users)
+
+	first, err := CreateReview(db, users[0].ID, product.ID, 5, "great")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := CreateReview(db, users[1].ID, product.ID, 2, "meh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rating := func() Product {
+		var p Product
+		db.First(&p, product.ID)
+		return p
+	}
+	if p := rating(); p.ReviewCount != 0 {
+		t.Errorf("pending reviews must not count, got %d", p.ReviewCount)
+	}
+
+	ModerateReview(db, first.ID, ReviewApproved)
+	ModerateReview(db, second.ID, ReviewApproved)
+	if p := rating(); p.ReviewCount != 2 || p.AverageRating != 3.5 {
+		t.Errorf("expected 2 reviews averaging 3.5, got %d %.2f", p.ReviewCount, p.AverageRating)
+	}
+
+	// Deleting by primary key only still refreshes the product
+	if err := db.Delete(&Review{}, second.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if p := rating(); p.ReviewCount != 1 || p.AverageRating != 5 {
+		t.Errorf("expected 1 review averaging 5 after delete, got %d %.2f", p.ReviewCount, p.AverageRating)
+	}
+
+	if err := db.Delete(&Review{}, 9999).Error; !errors.Is(err, ErrUnknownReviewProduct) {
+		t.Errorf("expected ErrUnknownReviewProduct for a missing review, got %v", err)
+	}
+
+	// Deleting several reviews at once refreshes every product they were on
+	other := Product{Name: "Cap", Price: 9.99}
+	db.Create(&other)
+	third, err := CreateReview(db, users[0].ID, other.ID, 4, "nice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ModerateReview(db, third.ID, ReviewApproved)
+	if err := db.Where("user_id = ?", users[0].ID).Delete(&Review{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint{product.ID, other.ID} {
+		var p Product
+		db.First(&p, id)
+		if p.ReviewCount != 0 || p.AverageRating != 0 {
+			t.Errorf("expected product %d to have no reviews left, got %d %.2f", id, p.ReviewCount, p.AverageRating)
+		}
+	}
+}
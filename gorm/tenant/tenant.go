@@ -0,0 +1,186 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrMissingTenant is returned when a tenant-scoped model is queried
+// without a tenant in the statement context
+var ErrMissingTenant = errors.New("tenant: no tenant in context")
+
+type contextKey struct{}
+
+type skipKey struct{}
+
+// WithTenant returns a copy of ctx carrying the given tenant ID
+func WithTenant(ctx context.Context, tenantID uint) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// FromContext returns the tenant ID stored in ctx, if any
+func FromContext(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	tenantID, ok := ctx.Value(contextKey{}).(uint)
+	return tenantID, ok && tenantID != 0
+}
+
+// SkipScope returns a copy of ctx that bypasses tenant scoping, e.g. for
+// migrations or administrative jobs that work across every tenant
+func SkipScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+func skipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
+
+// Plugin scopes every model that has a TenantID field to the tenant found
+// in the statement context. Creates get TenantID filled in, queries,
+// updates and deletes get a "tenant_id = ?" condition, and any of them
+// fails with ErrMissingTenant when the context carries no tenant. Updates
+// also get TenantID filled in, and fail when they would set another
+// tenant, so a row can't be moved out of its tenant.
+//
+// Raw and Exec are NOT scoped: GORM runs the SQL as written, so raw
+// statements must filter on tenant_id themselves.
+type Plugin struct{}
+
+// Name implements gorm.Plugin
+func (Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize implements gorm.Plugin
+func (p Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenant:assign", assignTenant); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:assign", pinTenant); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register("tenant:scope", scopeTenant)
+}
+
+// tenantField returns the TenantID field of the statement's model, or nil
+// when the model is not tenant-scoped or scoping was skipped
+func tenantField(db *gorm.DB) *schema.Field {
+	if db.Error != nil || db.Statement.Schema == nil || skipped(db.Statement.Context) {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField("TenantID")
+}
+
+func assignTenant(db *gorm.DB) {
+	field := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	tenantID, ok := FromContext(db.Statement.Context)
+	if !ok {
+		db.AddError(ErrMissingTenant)
+		return
+	}
+
+	// Create(map) and Create([]map) with Model(&T{}) carry the values in Dest
+	switch values := db.Statement.Dest.(type) {
+	case map[string]interface{}:
+		setMapTenant(db, field, values, tenantID)
+		return
+	case []map[string]interface{}:
+		for _, value := range values {
+			setMapTenant(db, field, value, tenantID)
+		}
+		return
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setTenant(db, field, reflect.Indirect(rv.Index(i)), tenantID)
+		}
+	case reflect.Struct:
+		setTenant(db, field, rv, tenantID)
+	}
+}
+
+// pinTenant fills in and checks the tenant of an update like assignTenant
+// does on create. Updates(struct) carries the new values in Dest rather
+// than in the model, so a tenant set there is checked too.
+func pinTenant(db *gorm.DB) {
+	assignTenant(db)
+
+	field := tenantField(db)
+	if field == nil {
+		return
+	}
+	tenantID, _ := FromContext(db.Statement.Context)
+	dest := reflect.Indirect(reflect.ValueOf(db.Statement.Dest))
+	if dest.Kind() != reflect.Struct || dest.Type() != db.Statement.Schema.ModelType {
+		return
+	}
+	if current, zero := field.ValueOf(db.Statement.Context, dest); !zero && current != tenantID {
+		db.AddError(errors.New("tenant: row belongs to another tenant"))
+	}
+}
+
+func setTenant(db *gorm.DB, field *schema.Field, rv reflect.Value, tenantID uint) {
+	if current, zero := field.ValueOf(db.Statement.Context, rv); !zero && current != tenantID {
+		db.AddError(errors.New("tenant: row belongs to another tenant"))
+		return
+	}
+	db.AddError(field.Set(db.Statement.Context, rv, tenantID))
+}
+
+// setMapTenant sets the tenant column of a map create, accepting a tenant
+// the caller already set under the field or column name if it matches
+func setMapTenant(db *gorm.DB, field *schema.Field, values map[string]interface{}, tenantID uint) {
+	for _, key := range []string{field.Name, field.DBName} {
+		current, ok := values[key]
+		if !ok {
+			continue
+		}
+		if id, ok := current.(uint); !ok || id != tenantID {
+			db.AddError(errors.New("tenant: row belongs to another tenant"))
+		}
+		return
+	}
+	values[field.DBName] = tenantID
+}
+
+func scopeTenant(db *gorm.DB) {
+	field := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	tenantID, ok := FromContext(db.Statement.Context)
+	if !ok {
+		db.AddError(ErrMissingTenant)
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
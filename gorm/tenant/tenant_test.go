@@ -0,0 +1,142 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type note struct {
+	ID       uint
+	TenantID uint
+	Text     string
+}
+
+type global struct {
+	ID   uint
+	Name string
+}
+
+// dryRun returns a DB that builds SQL without a database server
+func dryRun(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{
+		DryRun: true,
+		// Create, update and delete would otherwise open a transaction
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMissingTenant(t *testing.T) {
+	db := dryRun(t)
+
+	if err := db.Find(&[]note{}).Error; !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("query: expected ErrMissingTenant, got %v", err)
+	}
+	if err := db.Create(&note{Text: "x"}).Error; !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("create: expected ErrMissingTenant, got %v", err)
+	}
+	if err := db.Find(&[]global{}).Error; err != nil {
+		t.Errorf("models without TenantID need no tenant, got %v", err)
+	}
+	if err := db.WithContext(SkipScope(context.Background())).Find(&[]note{}).Error; err != nil {
+		t.Errorf("SkipScope: expected no error, got %v", err)
+	}
+}
+
+func TestScopesStatements(t *testing.T) {
+	db := dryRun(t).WithContext(WithTenant(context.Background(), 7))
+
+	statements := map[string]*gorm.DB{
+		"query":  db.Where("text = ?", "x").Find(&[]note{}),
+		"update": db.Model(&note{}).Where("id = ?", 1).Update("text", "y"),
+		"delete": db.Where("id = ?", 1).Delete(&note{}),
+	}
+	for name, result := range statements {
+		if result.Error != nil {
+			t.Errorf("%s: %v", name, result.Error)
+			continue
+		}
+		sql := result.Statement.SQL.String()
+		if !strings.Contains(sql, `"notes"."tenant_id" = $`) {
+			t.Errorf("%s: expected tenant condition in %s", name, sql)
+		}
+		if vars := result.Statement.Vars; vars[len(vars)-1] != uint(7) {
+			t.Errorf("%s: expected tenant 7 as last parameter, got %v", name, vars)
+		}
+	}
+}
+
+func TestAssignsTenantOnCreate(t *testing.T) {
+	db := dryRun(t).WithContext(WithTenant(context.Background(), 7))
+
+	row := note{Text: "x"}
+	if err := db.Create(&row).Error; err != nil || row.TenantID != 7 {
+		t.Errorf("struct: expected tenant 7, got %d (%v)", row.TenantID, err)
+	}
+
+	rows := []note{{Text: "a"}, {Text: "b"}}
+	if err := db.Create(&rows).Error; err != nil || rows[0].TenantID != 7 || rows[1].TenantID != 7 {
+		t.Errorf("slice: expected tenant 7, got %+v (%v)", rows, err)
+	}
+
+	values := map[string]interface{}{"text": "m"}
+	result := db.Model(&note{}).Create(values)
+	if result.Error != nil || values["tenant_id"] != uint(7) ||
+		!strings.Contains(result.Statement.SQL.String(), "tenant_id") {
+		t.Errorf("map: expected tenant_id column, got %v (%v)", result.Statement.SQL.String(), result.Error)
+	}
+
+	other := note{TenantID: 8, Text: "x"}
+	if err := db.Create(&other).Error; err == nil {
+		t.Error("expected error creating a row of another tenant")
+	}
+	if err := db.Model(&note{}).Create(map[string]interface{}{"TenantID": uint(8)}).Error; err == nil {
+		t.Error("expected error creating a map row of another tenant")
+	}
+}
+
+func TestPinsTenantOnUpdate(t *testing.T) {
+	db := dryRun(t).WithContext(WithTenant(context.Background(), 7))
+
+	// Save writes every column, so a zero TenantID would orphan the row
+	row := note{ID: 5, Text: "y"}
+	result := db.Save(&row)
+	if result.Error != nil || row.TenantID != 7 {
+		t.Fatalf("save: expected tenant 7, got %d (%v)", row.TenantID, result.Error)
+	}
+	if sql, vars := result.Statement.SQL.String(), result.Statement.Vars; !strings.Contains(sql, `SET "tenant_id"=$1`) || vars[0] != uint(7) {
+		t.Errorf("save: expected tenant_id pinned to 7, got %s %v", sql, vars)
+	}
+
+	moves := map[string]*gorm.DB{
+		"map column":   db.Model(&note{ID: 5}).Updates(map[string]interface{}{"tenant_id": uint(8)}),
+		"map field":    db.Model(&note{ID: 5}).Updates(map[string]interface{}{"TenantID": uint(8)}),
+		"single":       db.Model(&note{ID: 5}).Update("tenant_id", uint(8)),
+		"struct":       db.Model(&note{ID: 5}).Updates(note{TenantID: 8}),
+		"save another": db.Save(&note{ID: 5, TenantID: 8}),
+	}
+	for name, result := range moves {
+		if result.Error == nil {
+			t.Errorf("%s: expected error moving a row to another tenant, got %s", name, result.Statement.SQL.String())
+		}
+	}
+
+	if err := db.Model(&note{ID: 5}).Updates(map[string]interface{}{"text": "z"}).Error; err != nil {
+		t.Errorf("expected updates within the tenant to pass, got %v", err)
+	}
+}
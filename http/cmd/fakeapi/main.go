@@ -0,0 +1,19 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/guilhermehermes/curso-go/http/fakeapi"
+)
+
+// Serves an in-memory fake of jsonplaceholder.typicode.com. Point the
+// http example at it with: go run . -base-url http://localhost:3000
+func main() {
+	addr := flag.String("addr", ":3000", "address to listen on")
+	flag.Parse()
+
+	log.Printf("Fake JSONPlaceholder API listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, fakeapi.New()))
+}
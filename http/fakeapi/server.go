@@ -0,0 +1,360 @@
+// Package fakeapi is an in-memory fake of the JSONPlaceholder API, with the
+// same JSON shapes, for running the http examples and tests offline.
+package fakeapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/guilhermehermes/curso-go/http/jsonplaceholder"
+)
+
+type item = map[string]interface{}
+
+// Server serves /posts, /comments, /users, /todos and /albums. Mutations
+// are kept in memory for the lifetime of the Server.
+type Server struct {
+	mux *http.ServeMux
+
+	mu        sync.Mutex
+	resources map[string]*collection
+}
+
+type collection struct {
+	items  map[int]item
+	nextID int
+}
+
+// New returns a Server seeded with deterministic sample data: 10 users,
+// each with 10 posts, 20 todos and 10 albums, and 5 comments per post
+func New() *Server {
+	s := &Server{mux: http.NewServeMux(), resources: map[string]*collection{}}
+	s.seed()
+
+	s.mux.HandleFunc("GET /{resource}", s.list)
+	s.mux.HandleFunc("GET /{resource}/{id}", s.get)
+	s.mux.HandleFunc("GET /{resource}/{id}/{nested}", s.nested)
+	s.mux.HandleFunc("POST /{resource}", s.create)
+	s.mux.HandleFunc("PUT /{resource}/{id}", s.update)
+	s.mux.HandleFunc("PATCH /{resource}/{id}", s.update)
+	s.mux.HandleFunc("DELETE /{resource}/{id}", s.delete)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// list handles GET /posts?userId=1, filtering on any top-level field.
+// Like json-server, _page and _limit paginate and set a Link header.
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.resources[r.PathValue("resource")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, item{})
+		return
+	}
+	writeJSON(w, http.StatusOK, paginate(w, r, c.filter(r.URL.Query())))
+}
+
+// paginate returns the page of items selected by _page and _limit, or every
+// item when _page is absent
+func paginate(w http.ResponseWriter, r *http.Request, items []item) []item {
+	query := r.URL.Query()
+	page, err := strconv.Atoi(query.Get("_page"))
+	if err != nil || page < 1 {
+		return items
+	}
+	limit, err := strconv.Atoi(query.Get("_limit"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	last := (len(items) + limit - 1) / limit
+	if last < 1 {
+		last = 1
+	}
+	link := func(page int, rel string) string {
+		query.Set("_page", strconv.Itoa(page))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), rel)
+	}
+	links := []string{link(1, "first")}
+	if page > 1 {
+		links = append(links, link(page-1, "prev"))
+	}
+	if page < last {
+		links = append(links, link(page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(items)))
+
+	start := (page - 1) * limit
+	if start >= len(items) {
+		return []item{}
+	}
+	return items[start:min(start+limit, len(items))]
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, found, ok := s.lookup(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, item{})
+		return
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+// nested handles GET /posts/1/comments, i.e. comments whose postId is 1
+func (s *Server) nested(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	children, ok := s.resources[r.PathValue("nested")]
+	if _, _, parentOK := s.lookup(r); !parentOK || !ok {
+		writeJSON(w, http.StatusNotFound, item{})
+		return
+	}
+
+	query := r.URL.Query()
+	query.Set(foreignKey(r.PathValue("resource")), r.PathValue("id"))
+	writeJSON(w, http.StatusOK, children.filter(query))
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	body, err := decodeItem(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, item{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.resources[r.PathValue("resource")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, item{})
+		return
+	}
+	writeJSON(w, http.StatusCreated, c.add(body))
+}
+
+// update handles PUT (replace) and PATCH (merge)
+func (s *Server) update(w http.ResponseWriter, r *http.Request) {
+	body, err := decodeItem(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, item{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, found, ok := s.lookup(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, item{})
+		return
+	}
+
+	updated := body
+	if r.Method == http.MethodPatch {
+		updated = found
+		for key, value := range body {
+			updated[key] = value
+		}
+	}
+	updated["id"] = found["id"]
+	c.items[int(found["id"].(float64))] = updated
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, found, ok := s.lookup(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, item{})
+		return
+	}
+	delete(c.items, int(found["id"].(float64)))
+	writeJSON(w, http.StatusOK, item{})
+}
+
+// decodeItem reads a JSON object from the request body. A null body would
+// decode into a nil map, so it is rejected like any other non-object.
+func decodeItem(r *http.Request) (item, error) {
+	var body item
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, errors.New("body must be a JSON object")
+	}
+	return body, nil
+}
+
+// lookup finds the item for {resource}/{id}. Must be called with s.mu held.
+func (s *Server) lookup(r *http.Request) (*collection, item, bool) {
+	c, ok := s.resources[r.PathValue("resource")]
+	if !ok {
+		return nil, nil, false
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, nil, false
+	}
+	found, ok := c.items[id]
+	return c, found, ok
+}
+
+func (c *collection) add(body item) item {
+	c.nextID++
+	body["id"] = float64(c.nextID)
+	c.items[c.nextID] = body
+	return body
+}
+
+// filter returns the items, ordered by ID, whose fields match every query parameter
+func (c *collection) filter(query map[string][]string) []item {
+	ids := make([]int, 0, len(c.items))
+	for id := range c.items {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	result := []item{}
+	for _, id := range ids {
+		if matches(c.items[id], query) {
+			result = append(result, c.items[id])
+		}
+	}
+	return result
+}
+
+func matches(i item, query map[string][]string) bool {
+	for key, values := range query {
+		if strings.HasPrefix(key, "_") {
+			continue
+		}
+		value, ok := i[key]
+		if !ok {
+			return false
+		}
+		matched := false
+		for _, want := range values {
+			if fmt.Sprint(value) == want {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// foreignKey turns a parent resource into the child field, e.g. posts -> postId
+func foreignKey(resource string) string {
+	return strings.TrimSuffix(resource, "s") + "Id"
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (s *Server) seed() {
+	var (
+		users    []jsonplaceholder.User
+		posts    []jsonplaceholder.Post
+		comments []jsonplaceholder.Comment
+		todos    []jsonplaceholder.Todo
+		albums   []jsonplaceholder.Album
+	)
+
+	for u := 1; u <= 10; u++ {
+		users = append(users, jsonplaceholder.User{
+			ID:       u,
+			Name:     fmt.Sprintf("User %d", u),
+			Username: fmt.Sprintf("user%d", u),
+			Email:    fmt.Sprintf("user%d@example.com", u),
+			Address: jsonplaceholder.Address{
+				Street:  fmt.Sprintf("Street %d", u),
+				City:    "Gwenborough",
+				Zipcode: fmt.Sprintf("%05d-0000", u),
+				Geo:     jsonplaceholder.Geo{Lat: "-37.3159", Lng: "81.1496"},
+			},
+			Phone:   fmt.Sprintf("1-770-736-%04d", u),
+			Website: fmt.Sprintf("user%d.example.com", u),
+			Company: jsonplaceholder.Company{Name: fmt.Sprintf("Company %d", u)},
+		})
+
+		for p := 1; p <= 10; p++ {
+			postID := (u-1)*10 + p
+			posts = append(posts, jsonplaceholder.Post{
+				UserID: u,
+				ID:     postID,
+				Title:  fmt.Sprintf("Post %d by user %d", postID, u),
+				Body:   fmt.Sprintf("Body of post %d", postID),
+			})
+			for c := 1; c <= 5; c++ {
+				commentID := (postID-1)*5 + c
+				comments = append(comments, jsonplaceholder.Comment{
+					PostID: postID,
+					ID:     commentID,
+					Name:   fmt.Sprintf("Comment %d", commentID),
+					Email:  fmt.Sprintf("commenter%d@example.com", commentID),
+					Body:   fmt.Sprintf("Comment %d on post %d", commentID, postID),
+				})
+			}
+			albums = append(albums, jsonplaceholder.Album{UserID: u, ID: postID, Title: fmt.Sprintf("Album %d", postID)})
+		}
+
+		for t := 1; t <= 20; t++ {
+			todoID := (u-1)*20 + t
+			todos = append(todos, jsonplaceholder.Todo{
+				UserID:    u,
+				ID:        todoID,
+				Title:     fmt.Sprintf("Todo %d", todoID),
+				Completed: todoID%2 == 0,
+			})
+		}
+	}
+
+	s.load("users", users)
+	s.load("posts", posts)
+	s.load("comments", comments)
+	s.load("todos", todos)
+	s.load("albums", albums)
+}
+
+// load stores typed values as generic JSON objects, so responses keep the
+// exact field names of the jsonplaceholder types
+func (s *Server) load(resource string, values interface{}) {
+	data, _ := json.Marshal(values)
+	var items []item
+	json.Unmarshal(data, &items)
+
+	c := &collection{items: map[int]item{}}
+	for _, i := range items {
+		id := int(i["id"].(float64))
+		c.items[id] = i
+		if id > c.nextID {
+			c.nextID = id
+		}
+	}
+	s.resources[resource] = c
+}
@@ -0,0 +1,116 @@
+package fakeapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/guilhermehermes/curso-go/http/jsonplaceholder"
+)
+
+func TestFakeAPIWithClient(t *testing.T) {
+	server := httptest.NewServer(New())
+	defer server.Close()
+
+	client := jsonplaceholder.New(server.URL, nil)
+	ctx := context.Background()
+
+	posts, err := client.Posts().List(ctx, url.Values{"userId": {"3"}})
+	if err != nil || len(posts) != 10 || posts[0].UserID != 3 {
+		t.Fatalf("filter by userId: %v %d posts", err, len(posts))
+	}
+
+	comments, err := client.PostComments(ctx, posts[0].ID)
+	if err != nil || len(comments) != 5 || comments[0].PostID != posts[0].ID {
+		t.Fatalf("nested comments: %v %+v", err, comments)
+	}
+
+	created, err := client.Posts().Create(ctx, jsonplaceholder.Post{UserID: 3, Title: "new"})
+	if err != nil || created.ID != 101 {
+		t.Fatalf("create: %v %+v", err, created)
+	}
+	posts, _ = client.Posts().List(ctx, url.Values{"userId": {"3"}})
+	if len(posts) != 11 {
+		t.Errorf("expected created post to persist, got %d posts", len(posts))
+	}
+
+	if _, err := client.Posts().Update(ctx, created.ID, jsonplaceholder.Post{UserID: 3, Title: "changed"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	fetched, _ := client.Posts().Get(ctx, created.ID)
+	if fetched.Title != "changed" {
+		t.Errorf("expected update to persist, got %+v", fetched)
+	}
+
+	if err := client.Posts().Delete(ctx, created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = client.Posts().Get(ctx, created.ID)
+	var apiErr *jsonplaceholder.APIError
+	if !errors.As(err, &apiErr) || !apiErr.NotFound() {
+		t.Errorf("expected 404 after delete, got %v", err)
+	}
+
+	user, err := client.Users().Get(ctx, 1)
+	if err != nil || user.Email != "user1@example.com" {
+		t.Errorf("users: %v %+v", err, user)
+	}
+}
+
+func TestFakeAPIPagination(t *testing.T) {
+	server := httptest.NewServer(New())
+	defer server.Close()
+
+	client := jsonplaceholder.New(server.URL, nil)
+
+	ids := []int{}
+	for post, err := range client.Posts().All(context.Background(), url.Values{"userId": {"2"}}, 3) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, post.ID)
+	}
+	if len(ids) != 10 || ids[0] != 11 || ids[9] != 20 {
+		t.Errorf("expected posts 11..20 across pages, got %v", ids)
+	}
+
+	// Every page is full, so the end is only known from the missing rel="next"
+	count := 0
+	for _, err := range client.Comments().All(context.Background(), nil, 100) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count != 500 {
+		t.Errorf("expected 500 comments, got %d", count)
+	}
+}
+
+func TestFakeAPIRejectsNonObjectBodies(t *testing.T) {
+	server := New()
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch} {
+		target := "/posts/1"
+		if method == http.MethodPost {
+			target = "/posts"
+		}
+		for _, body := range []string{"null", "[]", "1"} {
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("%s %s with %s: expected 400, got %d", method, target, body, recorder.Code)
+			}
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/posts/1", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"title"`) {
+		t.Errorf("expected post 1 untouched, got %d %s", recorder.Code, recorder.Body)
+	}
+}
@@ -0,0 +1,7 @@
+module github.com/guilhermehermes/curso-go/http
+
+go 1.24.4
+
+require github.com/guilhermehermes/curso-go/httpclient v0.0.0
+
+replace github.com/guilhermehermes/curso-go/httpclient => ../httpclient
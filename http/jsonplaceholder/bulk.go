@@ -0,0 +1,164 @@
+package jsonplaceholder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BulkOptions controls GetMany. Zero values use the defaults noted on each field.
+type BulkOptions struct {
+	// Workers is how many requests run at the same time (default 4)
+	Workers int
+	// RatePerSecond limits requests per second across all workers; zero means no limit
+	RatePerSecond float64
+	// Burst is how many requests may start at once before the rate applies (default 1)
+	Burst int
+	// MaxRetries is how many times an item is retried after a 429 (default 3)
+	MaxRetries int
+	// DefaultRetryAfter is the wait after a 429 without Retry-After (default 1s)
+	DefaultRetryAfter time.Duration
+}
+
+// Result is the outcome of fetching one ID
+type Result[T any] struct {
+	ID   int
+	Item T
+	Err  error
+}
+
+// GetMany fetches every ID concurrently and returns the results in the same
+// order as ids. A failed ID does not stop the others; its error is in Result.Err.
+// On 429 every worker pauses for the server's Retry-After before retrying.
+func (r Resource[T]) GetMany(ctx context.Context, ids []int, options BulkOptions) []Result[T] {
+	if options.Workers <= 0 {
+		options.Workers = 4
+	}
+	if options.MaxRetries == 0 {
+		options.MaxRetries = 3
+	}
+	if options.DefaultRetryAfter == 0 {
+		options.DefaultRetryAfter = time.Second
+	}
+	limiter := newTokenBucket(options.RatePerSecond, options.Burst)
+
+	results := make([]Result[T], len(ids))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < options.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = r.fetchWithRetry(ctx, ids[i], limiter, options)
+			}
+		}()
+	}
+
+	for i := range ids {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func (r Resource[T]) fetchWithRetry(ctx context.Context, id int, limiter *tokenBucket, options BulkOptions) Result[T] {
+	result := Result[T]{ID: id}
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx); err != nil {
+			result.Err = err
+			return result
+		}
+
+		result.Item, result.Err = r.Get(ctx, id)
+
+		var apiErr *APIError
+		if !errors.As(result.Err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || attempt >= options.MaxRetries {
+			return result
+		}
+
+		wait := apiErr.RetryAfter
+		if wait <= 0 {
+			wait = options.DefaultRetryAfter
+		}
+		limiter.pause(wait)
+	}
+}
+
+// tokenBucket allows rate requests per second with bursts of up to burst.
+// A nil *tokenBucket or a zero rate never blocks, except while paused.
+type tokenBucket struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait blocks until a token is available, the pause is over, or ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		delay := b.reserve()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before trying again
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(b.pausedUntil) {
+		return b.pausedUntil.Sub(now)
+	}
+	if b.rate <= 0 {
+		return 0
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// pause stops every waiter until d from now, as asked by a Retry-After
+func (b *tokenBucket) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
@@ -0,0 +1,85 @@
+package jsonplaceholder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetManyOrderAndErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.URL.Path[len("/posts/"):])
+		if id == 3 {
+			http.NotFound(w, r)
+			return
+		}
+		// Later IDs answer first, so order must come from the input
+		time.Sleep(time.Duration(10-id) * time.Millisecond)
+		json.NewEncoder(w).Encode(Post{ID: id})
+	}))
+	defer server.Close()
+
+	ids := []int{1, 2, 3, 4, 5, 6}
+	results := New(server.URL, nil).Posts().GetMany(context.Background(), ids, BulkOptions{Workers: 3})
+
+	for i, result := range results {
+		if result.ID != ids[i] {
+			t.Errorf("result %d: expected ID %d, got %d", i, ids[i], result.ID)
+		}
+		var apiErr *APIError
+		if ids[i] == 3 {
+			if !errors.As(result.Err, &apiErr) || !apiErr.NotFound() {
+				t.Errorf("expected 404 for ID 3, got %v", result.Err)
+			}
+			continue
+		}
+		if result.Err != nil || result.Item.ID != ids[i] {
+			t.Errorf("result %d: %v %+v", i, result.Err, result.Item)
+		}
+	}
+}
+
+func TestGetManyRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Post{})
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	New(server.URL, nil).Posts().GetMany(context.Background(), []int{1, 2, 3, 4, 5},
+		BulkOptions{Workers: 5, RatePerSecond: 50, Burst: 1})
+
+	// 1 token up front, then 4 more at 50/s
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("expected rate limit to spread requests, took %v", elapsed)
+	}
+}
+
+func TestGetManyHonoursRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(Post{ID: 1})
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	results := New(server.URL, nil).Posts().GetMany(context.Background(), []int{1}, BulkOptions{})
+
+	if results[0].Err != nil || results[0].Item.ID != 1 {
+		t.Fatalf("expected retry to succeed, got %v", results[0].Err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait for Retry-After, took %v", elapsed)
+	}
+}
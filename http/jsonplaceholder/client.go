@@ -0,0 +1,195 @@
+// Package jsonplaceholder is a typed client for JSONPlaceholder-style APIs
+// (https://jsonplaceholder.typicode.com): posts, comments, users, todos and albums.
+package jsonplaceholder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the public JSONPlaceholder API
+const DefaultBaseURL = "https://jsonplaceholder.typicode.com"
+
+// APIError is returned for every non-2xx response
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+	// RetryAfter is the wait requested by the server's Retry-After header, if any
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("jsonplaceholder: %s %s returned %d: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// NotFound reports whether the resource does not exist
+func (e *APIError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// Client talks to a JSONPlaceholder-style API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New returns a Client for baseURL (DefaultBaseURL when empty) using
+// httpClient (http.DefaultClient when nil)
+func New(baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: httpClient}
+}
+
+// Resource is a REST collection of T, such as /posts
+type Resource[T any] struct {
+	client *Client
+	path   string
+}
+
+func (c *Client) Posts() Resource[Post]       { return Resource[Post]{c, "/posts"} }
+func (c *Client) Comments() Resource[Comment] { return Resource[Comment]{c, "/comments"} }
+func (c *Client) Users() Resource[User]       { return Resource[User]{c, "/users"} }
+func (c *Client) Todos() Resource[Todo]       { return Resource[Todo]{c, "/todos"} }
+func (c *Client) Albums() Resource[Album]     { return Resource[Album]{c, "/albums"} }
+
+// PostComments lists the comments of a post through the nested /posts/{id}/comments route
+func (c *Client) PostComments(ctx context.Context, postID int) ([]Comment, error) {
+	var comments []Comment
+	err := c.do(ctx, http.MethodGet, "/posts/"+strconv.Itoa(postID)+"/comments", nil, &comments)
+	return comments, err
+}
+
+// List returns the collection, filtered by query (e.g. userId=1) when not nil
+func (r Resource[T]) List(ctx context.Context, query url.Values) ([]T, error) {
+	path := r.path
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var items []T
+	err := r.client.do(ctx, http.MethodGet, path, nil, &items)
+	return items, err
+}
+
+// Get returns the item with the given ID
+func (r Resource[T]) Get(ctx context.Context, id int) (T, error) {
+	var item T
+	err := r.client.do(ctx, http.MethodGet, r.itemPath(id), nil, &item)
+	return item, err
+}
+
+// Create adds item and returns it as stored by the server, with its ID
+func (r Resource[T]) Create(ctx context.Context, item T) (T, error) {
+	var created T
+	err := r.client.do(ctx, http.MethodPost, r.path, item, &created)
+	return created, err
+}
+
+// Update replaces the item with the given ID
+func (r Resource[T]) Update(ctx context.Context, id int, item T) (T, error) {
+	var updated T
+	err := r.client.do(ctx, http.MethodPut, r.itemPath(id), item, &updated)
+	return updated, err
+}
+
+// Delete removes the item with the given ID
+func (r Resource[T]) Delete(ctx context.Context, id int) error {
+	return r.client.do(ctx, http.MethodDelete, r.itemPath(id), nil, nil)
+}
+
+func (r Resource[T]) itemPath(id int) string {
+	return r.path + "/" + strconv.Itoa(id)
+}
+
+// do sends body as JSON, when not nil, and decodes the response into out, when not nil
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	response, err := c.send(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	// The transport reuses the connection only if the body was read to the
+	// end, which Delete and a decoder stopping after the value don't do
+	defer func() {
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+	}()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(out); err != nil {
+		return fmt.Errorf("jsonplaceholder: decoding %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// send sends body as JSON, when not nil, to rawURL. Non-2xx responses are
+// returned as *APIError; otherwise the caller must close the response body.
+func (c *Client) send(ctx context.Context, method, rawURL string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		defer response.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+		return nil, &APIError{
+			Method:     method,
+			URL:        request.URL.String(),
+			StatusCode: response.StatusCode,
+			Body:       strings.TrimSpace(string(data)),
+			RetryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+		}
+	}
+	return response, nil
+}
+
+// parseRetryAfter accepts both forms of Retry-After: seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
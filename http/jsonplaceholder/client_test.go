@@ -0,0 +1,106 @@
+package jsonplaceholder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// fakeAPI serves a single post and its comments, and echoes writes back
+func fakeAPI(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /posts", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("userId") != "1" {
+			t.Errorf("expected userId filter, got %q", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode([]Post{{UserID: 1, ID: 1, Title: "first"}})
+	})
+	mux.HandleFunc("GET /posts/1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Post{UserID: 1, ID: 1, Title: "first"})
+	})
+	mux.HandleFunc("GET /posts/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]Comment{{PostID: 1, ID: 1}, {PostID: 1, ID: 2}})
+	})
+	mux.HandleFunc("POST /posts", func(w http.ResponseWriter, r *http.Request) {
+		var post Post
+		json.NewDecoder(r.Body).Decode(&post)
+		post.ID = 101
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(post)
+	})
+	mux.HandleFunc("PUT /posts/1", func(w http.ResponseWriter, r *http.Request) {
+		var post Post
+		json.NewDecoder(r.Body).Decode(&post)
+		post.ID = 1
+		json.NewEncoder(w).Encode(post)
+	})
+	mux.HandleFunc("DELETE /posts/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPostsCRUD(t *testing.T) {
+	client := New(fakeAPI(t).URL, nil)
+	posts := client.Posts()
+	ctx := context.Background()
+
+	list, err := posts.List(ctx, url.Values{"userId": {"1"}})
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list: %v %+v", err, list)
+	}
+
+	post, err := posts.Get(ctx, 1)
+	if err != nil || post.Title != "first" {
+		t.Fatalf("get: %v %+v", err, post)
+	}
+
+	created, err := posts.Create(ctx, Post{UserID: 1, Title: "new"})
+	if err != nil || created.ID != 101 || created.Title != "new" {
+		t.Fatalf("create: %v %+v", err, created)
+	}
+
+	updated, err := posts.Update(ctx, 1, Post{UserID: 1, Title: "changed"})
+	if err != nil || updated.Title != "changed" {
+		t.Fatalf("update: %v %+v", err, updated)
+	}
+
+	if err := posts.Delete(ctx, 1); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	comments, err := client.PostComments(ctx, 1)
+	if err != nil || len(comments) != 2 {
+		t.Fatalf("comments: %v %+v", err, comments)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	client := New(fakeAPI(t).URL, nil)
+
+	_, err := client.Todos().Get(context.Background(), 999)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if !apiErr.NotFound() || apiErr.Method != http.MethodGet {
+		t.Errorf("unexpected error details: %+v", apiErr)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	client := New(fakeAPI(t).URL, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.Posts().Get(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
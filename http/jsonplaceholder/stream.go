@@ -0,0 +1,184 @@
+package jsonplaceholder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DecodeArray decodes a JSON array from r one element at a time, so only the
+// current element is held in memory. Iteration stops at the first error,
+// which is yielded with the zero T.
+func DecodeArray[T any](r io.Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		decoder := json.NewDecoder(r)
+
+		token, err := decoder.Token()
+		if err != nil {
+			yield(zero, fmt.Errorf("jsonplaceholder: reading array: %w", err))
+			return
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			yield(zero, fmt.Errorf("jsonplaceholder: expected JSON array, got %v", token))
+			return
+		}
+
+		for decoder.More() {
+			var item T
+			if err := decoder.Decode(&item); err != nil {
+				yield(zero, fmt.Errorf("jsonplaceholder: decoding array element: %w", err))
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			yield(zero, fmt.Errorf("jsonplaceholder: reading array end: %w", err))
+		}
+	}
+}
+
+// Stream is List without buffering: the response is decoded while the
+// caller iterates, and closed when the loop ends or breaks
+func (r Resource[T]) Stream(ctx context.Context, query url.Values) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		rawURL := r.client.baseURL + r.path
+		if len(query) > 0 {
+			rawURL += "?" + query.Encode()
+		}
+		r.streamPage(ctx, rawURL, nil, yield)
+	}
+}
+
+// All iterates over the whole collection, pageSize items per request. It
+// follows the rel="next" Link header when the server sends one and otherwise
+// asks for the next _page until a page comes back short. A page that starts
+// with the same item as the previous one also ends the iteration, so a server
+// that ignores _page is not read forever.
+func (r Resource[T]) All(ctx context.Context, query url.Values, pageSize int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		if pageSize <= 0 {
+			pageSize = 10
+		}
+		params := url.Values{}
+		for key, values := range query {
+			params[key] = values
+		}
+		params.Set("_limit", strconv.Itoa(pageSize))
+
+		page := 1
+		params.Set("_page", strconv.Itoa(page))
+		rawURL := r.client.baseURL + r.path + "?" + params.Encode()
+
+		var first []byte
+		for rawURL != "" {
+			result, ok := r.streamPage(ctx, rawURL, first, yield)
+			if !ok {
+				return
+			}
+			first = result.first
+
+			switch {
+			case result.repeated:
+				rawURL = ""
+			case result.linked:
+				rawURL = result.next
+			case result.count < pageSize:
+				rawURL = ""
+			default:
+				page++
+				params.Set("_page", strconv.Itoa(page))
+				rawURL = r.client.baseURL + r.path + "?" + params.Encode()
+			}
+		}
+	}
+}
+
+// pageResult describes one list response streamed by streamPage
+type pageResult struct {
+	count int
+	// first is the JSON encoding of the first item; repeated is true when it
+	// matched the previous page's, in which case nothing was yielded
+	first    []byte
+	repeated bool
+	// linked is true when the server paginates with Link headers, in which
+	// case next is the absolute URL of the next page or empty on the last one
+	linked bool
+	next   string
+}
+
+// streamPage yields every item of one list response, unless its first item
+// encodes to previous. It returns false when iteration must stop because of
+// an error or because the caller broke out.
+func (r Resource[T]) streamPage(ctx context.Context, rawURL string, previous []byte, yield func(T, error) bool) (pageResult, bool) {
+	var zero T
+	var result pageResult
+
+	response, err := r.client.send(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		yield(zero, err)
+		return result, false
+	}
+	defer response.Body.Close()
+
+	for item, err := range DecodeArray[T](response.Body) {
+		if err != nil {
+			yield(zero, err)
+			return result, false
+		}
+		if result.count == 0 {
+			// An item that fails to encode leaves first nil, disabling the check
+			result.first, _ = json.Marshal(item)
+			if previous != nil && bytes.Equal(result.first, previous) {
+				result.repeated = true
+				return result, true
+			}
+		}
+		result.count++
+		if !yield(item, nil) {
+			return result, false
+		}
+	}
+
+	links := response.Header.Values("Link")
+	result.linked = len(links) > 0
+	if next := nextLink(links); next != "" {
+		// Relative links are resolved against the page that returned them
+		if ref, err := url.Parse(next); err == nil {
+			next = response.Request.URL.ResolveReference(ref).String()
+		}
+		result.next = next
+	}
+	return result, true
+}
+
+// nextLink returns the rel="next" target of RFC 8288 Link headers, e.g.
+// <https://api.example.com/posts?_page=2>; rel="next"
+func nextLink(headers []string) string {
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(name, "rel") && strings.Trim(value, `"`) == "next" {
+					return strings.Trim(target, "<>")
+				}
+			}
+		}
+	}
+	return ""
+}
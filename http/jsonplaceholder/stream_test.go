@@ -0,0 +1,106 @@
+package jsonplaceholder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDecodeArray(t *testing.T) {
+	input := `[{"id":1,"title":"a"},{"id":2,"title":"b"},{"id":3,"title":"c"}]`
+
+	ids := []int{}
+	for post, err := range DecodeArray[Post](strings.NewReader(input)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, post.ID)
+		if post.ID == 2 {
+			break
+		}
+	}
+	if len(ids) != 2 || ids[1] != 2 {
+		t.Errorf("expected to stop after 2 items, got %v", ids)
+	}
+}
+
+func TestDecodeArrayErrors(t *testing.T) {
+	for _, input := range []string{`{"id":1}`, `[{"id":1},{"id":`, ``} {
+		var lastErr error
+		for _, err := range DecodeArray[Post](strings.NewReader(input)) {
+			lastErr = err
+		}
+		if lastErr == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestAllFollowsPages(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		page, _ := strconv.Atoi(r.URL.Query().Get("_page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("_limit"))
+
+		// 7 posts in total and no Link header, so the paginator counts pages
+		posts := []Post{}
+		for id := (page-1)*limit + 1; id <= page*limit && id <= 7; id++ {
+			posts = append(posts, Post{ID: id})
+		}
+		json.NewEncoder(w).Encode(posts)
+	}))
+	defer server.Close()
+
+	ids := []int{}
+	for post, err := range New(server.URL, nil).Posts().All(context.Background(), nil, 3) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, post.ID)
+	}
+	if len(ids) != 7 || ids[6] != 7 {
+		t.Errorf("expected posts 1..7, got %v", ids)
+	}
+	if len(requests) != 3 {
+		t.Errorf("expected 3 page requests, got %v", requests)
+	}
+}
+
+func TestAllStopsWhenPagingIsIgnored(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Every request gets the whole collection, whatever _page and _limit say
+		json.NewEncoder(w).Encode([]Post{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}})
+	}))
+	defer server.Close()
+
+	ids := []int{}
+	for post, err := range New(server.URL, nil).Posts().All(context.Background(), nil, 3) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, post.ID)
+	}
+	if len(ids) != 4 || ids[3] != 4 {
+		t.Errorf("expected posts 1..4 once, got %v", ids)
+	}
+	if requests != 2 {
+		t.Errorf("expected to stop at the repeated second page, got %d requests", requests)
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	header := `</posts?_page=1>; rel="first", </posts?_page=3>; rel="next", </posts?_page=10>; rel="last"`
+	if next := nextLink([]string{header}); next != "/posts?_page=3" {
+		t.Errorf("expected next page link, got %q", next)
+	}
+	if next := nextLink([]string{`</posts?_page=1>; rel="first"`}); next != "" {
+		t.Errorf("expected no next link, got %q", next)
+	}
+}
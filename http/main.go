@@ -1,63 +1,83 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
-	"io"
-	"net/http"
-)
+	"log"
+	"time"
 
-// Post represents a blog post from the JSONPlaceholder API
-type Post struct {
-	UserID int    `json:"userId"`
-	ID     int    `json:"id"`
-	Title  string `json:"title"`
-	Body   string `json:"body"`
-}
+	"github.com/guilhermehermes/curso-go/http/jsonplaceholder"
+	"github.com/guilhermehermes/curso-go/httpclient"
+)
 
 func main() {
+	baseURL := flag.String("base-url", jsonplaceholder.DefaultBaseURL, "API base URL, e.g. http://localhost:3000 for cmd/fakeapi")
+	flag.Parse()
+
 	fmt.Println("Starting HTTP client example...")
 
-	// Make a GET request to the JSONPlaceholder API
-	response, err := http.Get("https://jsonplaceholder.typicode.com/posts/1")
+	// Client with timeouts, retries on 5xx/network errors and a circuit breaker
+	httpClient := httpclient.New(httpclient.Config{
+		Timeout:          10 * time.Second,
+		AttemptTimeout:   3 * time.Second,
+		MaxRetries:       3,
+		BreakerThreshold: 5,
+		Hooks:            httpclient.LogHooks(log.Default(), "jsonplaceholder"),
+	})
+	api := jsonplaceholder.New(*baseURL, httpClient)
+	ctx := context.Background()
+
+	// Fetch a single post from the JSONPlaceholder API
+	post, err := api.Posts().Get(ctx, 1)
 	if err != nil {
-		fmt.Printf("Error making request: %s\n", err)
+		fmt.Printf("Error fetching post: %s\n", err)
 		return
 	}
 
-	// Se você não usar 'defer response.Body.Close()' aqui, acontecerá:
-	// 1. Vazamento de recursos - o corpo da resposta não será fechado
-	// 2. Conexões TCP podem permanecer abertas
-	// 3. Descritores de arquivo não serão liberados
-	// 4. Em múltiplas requisições, pode esgotar recursos do sistema
-	// 5. Pode causar lentidão e até falhas no programa após uso prolongado
-
-	// Check if the response was successful
-	if response.StatusCode != http.StatusOK {
-		fmt.Printf("API returned non-200 response: %d %s\n",
-			response.StatusCode, response.Status)
-		return
-	}
+	// Print the post details
+	fmt.Println("Successfully fetched post:")
+	fmt.Printf("ID: %d\n", post.ID)
+	fmt.Printf("User ID: %d\n", post.UserID)
+	fmt.Printf("Title: %s\n", post.Title)
+	fmt.Printf("Body: %s\n", post.Body)
 
-	// Read the response body
-	body, err := io.ReadAll(response.Body)
+	// Nested resources and related users
+	comments, err := api.PostComments(ctx, post.ID)
 	if err != nil {
-		fmt.Printf("Error reading response body: %s\n", err)
+		fmt.Printf("Error fetching comments: %s\n", err)
 		return
 	}
-
-	// Parse the JSON response
-	var post Post
-	err = json.Unmarshal(body, &post)
+	author, err := api.Users().Get(ctx, post.UserID)
 	if err != nil {
-		fmt.Printf("Error parsing JSON: %s\n", err)
+		fmt.Printf("Error fetching author: %s\n", err)
 		return
 	}
+	fmt.Printf("Post has %d comments and was written by %s (%s)\n", len(comments), author.Name, author.Email)
 
-	// Print the post details
-	fmt.Println("Successfully fetched post:")
-	fmt.Printf("ID: %d\n", post.ID)
-	fmt.Printf("User ID: %d\n", post.UserID)
-	fmt.Printf("Title: %s\n", post.Title)
-	fmt.Printf("Body: %s\n", post.Body)
+	// Fetch several posts concurrently, at most 5 requests per second
+	results := api.Posts().GetMany(ctx, []int{2, 3, 4, 5, 6}, jsonplaceholder.BulkOptions{
+		Workers:       3,
+		RatePerSecond: 5,
+		Burst:         2,
+	})
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Printf("Post %d: error: %s\n", result.ID, result.Err)
+			continue
+		}
+		fmt.Printf("Post %d: %s\n", result.ID, result.Item.Title)
+	}
+
+	// Walk every comment page by page, decoding one comment at a time
+	count, emails := 0, map[string]bool{}
+	for comment, err := range api.Comments().All(ctx, nil, 100) {
+		if err != nil {
+			fmt.Printf("Error streaming comments: %s\n", err)
+			return
+		}
+		count++
+		emails[comment.Email] = true
+	}
+	fmt.Printf("Streamed %d comments from %d distinct authors\n", count, len(emails))
 }
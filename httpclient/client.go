@@ -0,0 +1,284 @@
+// Package httpclient cria um *http.Client resiliente: timeout por tentativa,
+// novas tentativas com backoff exponencial e jitter, circuit breaker e
+// ganchos para log de requisições e respostas.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen é devolvido sem chamar o servidor enquanto o circuito está aberto
+var ErrCircuitOpen = errors.New("httpclient: circuito aberto, servidor com falhas recentes")
+
+// Config define o comportamento do cliente. Valores zerados usam os padrões
+// indicados em cada campo.
+type Config struct {
+	// Timeout limita a chamada inteira, incluindo as novas tentativas (padrão 10s)
+	Timeout time.Duration
+	// AttemptTimeout limita cada tentativa; zero usa apenas Timeout
+	AttemptTimeout time.Duration
+	// MaxRetries é quantas vezes tentar de novo após a primeira falha (padrão 0)
+	MaxRetries int
+	// BaseDelay é a espera antes da primeira nova tentativa, dobrada a cada uma (padrão 100ms)
+	BaseDelay time.Duration
+	// MaxDelay limita a espera entre tentativas (padrão 2s)
+	MaxDelay time.Duration
+	// RetryNonIdempotent permite repetir POST e PATCH, que por padrão não são repetidos
+	RetryNonIdempotent bool
+	// BreakerThreshold é quantas falhas seguidas abrem o circuito; zero desliga o circuit breaker
+	BreakerThreshold int
+	// BreakerCooldown é quanto tempo o circuito fica aberto antes de deixar uma chamada de teste passar (padrão 30s)
+	BreakerCooldown time.Duration
+	// Hooks recebe eventos de cada tentativa
+	Hooks Hooks
+	// Transport faz as requisições de fato (padrão http.DefaultTransport)
+	Transport http.RoundTripper
+}
+
+// Hooks são chamados a cada tentativa, para log ou métricas
+type Hooks struct {
+	// OnRequest é chamado antes de cada tentativa, começando em 1
+	OnRequest func(req *http.Request, attempt int)
+	// OnResponse é chamado após cada tentativa, com a resposta ou o erro
+	OnResponse func(req *http.Request, resp *http.Response, err error, attempt int, duration time.Duration)
+	// OnBreakerChange é chamado quando o circuito abre ou fecha
+	OnBreakerChange func(open bool)
+}
+
+// New devolve um *http.Client que aplica config a todas as requisições
+func New(config Config) *http.Client {
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.BaseDelay == 0 {
+		config.BaseDelay = 100 * time.Millisecond
+	}
+	if config.MaxDelay == 0 {
+		config.MaxDelay = 2 * time.Second
+	}
+	if config.BreakerCooldown == 0 {
+		config.BreakerCooldown = 30 * time.Second
+	}
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: &transport{config: config, breaker: &breaker{config: config}},
+	}
+}
+
+type transport struct {
+	config  Config
+	breaker *breaker
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryable := t.config.RetryNonIdempotent || idempotent(req.Method)
+
+	for attempt := 1; ; attempt++ {
+		if !t.breaker.allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := t.attempt(req, attempt)
+		failed := err != nil || resp.StatusCode >= 500
+		// Um cancelamento de quem chamou (o perdedor de uma corrida, um cliente
+		// que desconectou) não diz nada sobre a saúde do servidor
+		if errors.Is(req.Context().Err(), context.Canceled) {
+			t.breaker.skip()
+		} else {
+			t.breaker.record(!failed)
+		}
+
+		if !failed || !retryable || attempt > t.config.MaxRetries || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		// O corpo precisa ser lido de novo na próxima tentativa
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if waitErr := sleep(req.Context(), t.backoff(attempt)); waitErr != nil {
+			return nil, waitErr
+		}
+	}
+}
+
+func (t *transport) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	if t.config.Hooks.OnRequest != nil {
+		t.config.Hooks.OnRequest(req, attempt)
+	}
+
+	attemptReq := req
+	var cancel context.CancelFunc = func() {}
+	if t.config.AttemptTimeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), t.config.AttemptTimeout)
+		attemptReq = req.WithContext(ctx)
+	}
+
+	start := time.Now()
+	resp, err := t.config.Transport.RoundTrip(attemptReq)
+	if t.config.Hooks.OnResponse != nil {
+		t.config.Hooks.OnResponse(req, resp, err, attempt, time.Since(start))
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// O timeout da tentativa vale até o corpo ser fechado
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff devolve a espera antes da próxima tentativa: BaseDelay dobrado a
+// cada tentativa, limitado a MaxDelay, com jitter entre metade e o valor cheio
+func (t *transport) backoff(attempt int) time.Duration {
+	delay := t.config.BaseDelay << (attempt - 1)
+	if delay > t.config.MaxDelay || delay <= 0 {
+		delay = t.config.MaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("httpclient: desistindo das novas tentativas: %w", ctx.Err())
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// breaker abre após BreakerThreshold falhas seguidas; depois de
+// BreakerCooldown deixa passar uma chamada de teste, que fecha o circuito se
+// der certo ou o reabre se falhar
+type breaker struct {
+	config Config
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	open     bool
+	probing  bool
+}
+
+func (b *breaker) allow() bool {
+	if b.config.BreakerThreshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < b.config.BreakerCooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// skip libera a sonda de um circuito aberto sem contar a tentativa
+func (b *breaker) skip() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+func (b *breaker) record(success bool) {
+	if b.config.BreakerThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	wasOpen := b.open
+	b.probing = false
+	if success {
+		b.failures = 0
+		b.open = false
+	} else {
+		b.failures++
+		if b.open || b.failures >= b.config.BreakerThreshold {
+			b.open = true
+			b.openedAt = time.Now()
+		}
+	}
+	changed := wasOpen != b.open
+	open := b.open
+	b.mu.Unlock()
+
+	if changed && b.config.Hooks.OnBreakerChange != nil {
+		b.config.Hooks.OnBreakerChange(open)
+	}
+}
+
+// LogHooks registra em logger as tentativas que falham e as mudanças do circuito
+func LogHooks(logger *log.Logger, name string) Hooks {
+	return Hooks{
+		OnResponse: func(req *http.Request, resp *http.Response, err error, attempt int, duration time.Duration) {
+			switch {
+			case err != nil:
+				logger.Printf("%s: %s %s tentativa %d falhou em %v: %v", name, req.Method, req.URL, attempt, duration, err)
+			case resp.StatusCode >= 500:
+				logger.Printf("%s: %s %s tentativa %d respondeu %d em %v", name, req.Method, req.URL, attempt, resp.StatusCode, duration)
+			}
+		},
+		OnBreakerChange: func(open bool) {
+			if open {
+				logger.Printf("%s: circuito aberto", name)
+			} else {
+				logger.Printf("%s: circuito fechado", name)
+			}
+		},
+	}
+}
@@ -0,0 +1,159 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flaky responde 503 nas primeiras falhas requisições e 200 depois
+func flaky(t *testing.T, falhas int32) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= falhas {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetriesServerErrors(t *testing.T) {
+	server, calls := flaky(t, 2)
+	client := New(Config{MaxRetries: 3, BaseDelay: time.Millisecond})
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("expected success on third attempt, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryResendsBody(t *testing.T) {
+	server, _ := flaky(t, 1)
+	client := New(Config{MaxRetries: 1, BaseDelay: time.Millisecond, RetryNonIdempotent: true})
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("corpo"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "corpo" {
+		t.Errorf("expected body to be sent again, got %q", body)
+	}
+}
+
+func TestDoesNotRetryPostByDefault(t *testing.T) {
+	server, calls := flaky(t, 1)
+	client := New(Config{MaxRetries: 3, BaseDelay: time.Millisecond})
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("corpo"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("expected a single POST attempt, got %d calls", calls.Load())
+	}
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	var attempts atomic.Int32
+	client := New(Config{
+		AttemptTimeout: 20 * time.Millisecond,
+		MaxRetries:     2,
+		BaseDelay:      time.Millisecond,
+		Hooks:          Hooks{OnRequest: func(*http.Request, int) { attempts.Add(1) }},
+	})
+
+	start := time.Now()
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if attempts.Load() != 3 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("expected 3 short attempts, got %d in %v", attempts.Load(), time.Since(start))
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	server, calls := flaky(t, 3)
+	var changes []bool
+	client := New(Config{
+		BreakerThreshold: 3,
+		BreakerCooldown:  50 * time.Millisecond,
+		Hooks:            Hooks{OnBreakerChange: func(open bool) { changes = append(changes, open) }},
+	})
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected open circuit to skip the server, got %d calls", calls.Load())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected probe to pass after cooldown: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("expected breaker to open then close, got %v", changes)
+	}
+}
+
+func TestCircuitBreakerIgnoresCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("lento") {
+			<-r.Context().Done()
+			return
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := New(Config{BreakerThreshold: 2, BreakerCooldown: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?lento", nil)
+		if _, err := client.Do(request); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected cancellations to leave the circuit closed: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,3 @@
+module github.com/guilhermehermes/curso-go/httpclient
+
+go 1.24.4
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+type croc struct {
+	ID    int    `json:"id"`
+	Model string `json:"model"`
+	Color string `json:"color"`
+	Size  int    `json:"size"`
+}
+
+// crocs is an in-memory collection served under /crocs
+type crocs struct {
+	mu     sync.Mutex
+	items  map[int]croc
+	nextID int
+}
+
+func newCrocs() *crocs {
+	c := &crocs{items: map[int]croc{}}
+	c.add(croc{Model: "Classic Clog", Color: "lime", Size: 42})
+	c.add(croc{Model: "Crocband", Color: "navy", Size: 39})
+	return c
+}
+
+func (c *crocs) add(item croc) croc {
+	c.nextID++
+	item.ID = c.nextID
+	c.items[item.ID] = item
+	return item
+}
+
+func (c *crocs) list(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	items := make([]croc, 0, len(c.items))
+	for _, item := range c.items {
+		items = append(items, item)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (c *crocs) get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	item, ok := c.items[id]
+	c.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (c *crocs) create(w http.ResponseWriter, r *http.Request) {
+	var item croc
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		// BodyLimit only rejects a declared Content-Length up front; a body
+		// that goes over the limit while streaming fails here
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid croc: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	item = c.add(item)
+	c.mu.Unlock()
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func (c *crocs) delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	_, ok := c.items[id]
+	delete(c.items, id)
+	c.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
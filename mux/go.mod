@@ -0,0 +1,7 @@
+module github.com/guilhermehermes/curso-go/mux
+
+go 1.24.4
+
+require github.com/guilhermehermes/curso-go/observability v0.0.0
+
+replace github.com/guilhermehermes/curso-go/observability => ../observability
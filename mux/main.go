@@ -1,17 +1,51 @@
 package main
 
 import (
+	"log/slog"
 	"net/http"
+	"os"
+
+	"github.com/guilhermehermes/curso-go/mux/middleware"
+	"github.com/guilhermehermes/curso-go/mux/router"
+	"github.com/guilhermehermes/curso-go/observability"
 )
 
 func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	obs := observability.New()
+
+	r := router.New()
+	r.Get("/{$}", HomeHandler)
+	r.Get("/healthz", obs.Healthz)
+	r.Get("/readyz", obs.Readyz)
+	r.Get("/metrics", obs.Metrics)
+
+	// Crocs routes share a prefix and accept at most 1 KiB of request body
+	c := newCrocs()
+	api := r.Group("/crocs", middleware.BodyLimit(1<<10))
+	api.Get("", c.list)
+	api.Post("", c.create)
+	api.Get("/{id}", c.get)
+	api.Delete("/{id}", c.delete)
+
+	// Global middleware, outermost first; also covers 404 and 405 responses
+	r.Use(
+		middleware.RequestID,
+		middleware.AccessLog(logger),
+		middleware.Recover(logger),
+		middleware.CORS(middleware.CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedHeaders: []string{"Content-Type", middleware.RequestIDHeader},
+			ExposedHeaders: []string{middleware.RequestIDHeader},
+		}),
+		middleware.Gzip,
+		// Innermost, so it sees the route pattern matched by the router
+		obs.Middleware,
+	)
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", HomeHandler)
-	mux.HandleFunc("/crocs", crocs{}.ServeHTTP)
 	server := &http.Server{
 		Addr:    ":8080",
-		Handler: mux,
+		Handler: r,
 	}
 
 	err := server.ListenAndServe()
@@ -21,25 +55,7 @@ func main() {
 }
 
 func HomeHandler(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
-		return
-	}
-
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Welcome to the Home Page!"))
 }
-
-type crocs struct{}
-
-func (c crocs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/crocs" {
-		http.NotFound(w, r)
-		return
-	}
-
-	w.Header().Set("Content-Type", "text/plain")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Welcome to the Crocs Page!"))
-}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig lists what cross-origin requests may do
+type CORSConfig struct {
+	// AllowedOrigins are exact origins such as https://example.com, or "*"
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, POST, PUT, PATCH, DELETE and HEAD
+	AllowedMethods []string
+	// AllowedHeaders are the request headers a preflight may ask for
+	AllowedHeaders []string
+	// ExposedHeaders are response headers readable by the browser
+	ExposedHeaders []string
+	// AllowCredentials allows cookies; CORS panics if it is combined with "*"
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// CORS answers preflight requests with 204 and adds the CORS response
+// headers to requests from allowed origins. Other origins get no CORS
+// headers, so the browser blocks them.
+func CORS(config CORSConfig) Middleware {
+	if len(config.AllowedMethods) == 0 {
+		config.AllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"}
+	}
+	anyOrigin := slices.Contains(config.AllowedOrigins, "*")
+	if anyOrigin && config.AllowCredentials {
+		// Echoing any origin with credentials would let every site make
+		// authenticated requests
+		panic(`middleware: CORS AllowCredentials cannot be combined with origin "*"`)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			header := w.Header()
+			header.Add("Vary", "Origin")
+
+			if origin == "" || !(anyOrigin || slices.Contains(config.AllowedOrigins, origin)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if config.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !preflight {
+				if len(config.ExposedHeaders) > 0 {
+					header.Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+			if len(config.AllowedHeaders) > 0 {
+				header.Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+			}
+			if config.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
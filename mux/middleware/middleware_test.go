@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := Chain(tag("a"), tag("b")).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if strings.Join(order, ",") != "a,b,handler" {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	var seen string
+	handler := Chain(RequestID, AccessLog(logger)).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	})
+
+	request := httptest.NewRequest("GET", "/teapot", nil)
+	request.Header.Set(RequestIDHeader, "abc123")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if seen != "abc123" || response.Header().Get(RequestIDHeader) != "abc123" {
+		t.Errorf("expected request ID to be propagated, got %q / %q", seen, response.Header().Get(RequestIDHeader))
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON log line, got %q", logs.String())
+	}
+	if entry["status"] != float64(418) || entry["bytes"] != float64(15) || entry["request_id"] != "abc123" || entry["path"] != "/teapot" {
+		t.Errorf("unexpected log entry %v", entry)
+	}
+
+	// Without a header a new ID is generated
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+	if seen == "" || seen == "abc123" {
+		t.Errorf("expected generated request ID, got %q", seen)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := Recover(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+	if response.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", response.Code)
+	}
+}
+
+func TestGzip(t *testing.T) {
+	body := strings.Repeat("crocs ", 100)
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, body)
+	}))
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Accept-Encoding", "br, gzip")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if response.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip encoding, got headers %v", response.Header())
+	}
+	reader, err := gzip.NewReader(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	if string(data) != body {
+		t.Errorf("unexpected body %q", data)
+	}
+
+	// Clients without gzip get the plain body
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+	if response.Header().Get("Content-Encoding") != "" || response.Body.String() != body {
+		t.Errorf("expected uncompressed response")
+	}
+}
+
+func TestCORS(t *testing.T) {
+	called := false
+	handler := CORS(CORSConfig{
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedHeaders: []string{"Content-Type"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	preflight := httptest.NewRequest("OPTIONS", "/crocs", nil)
+	preflight.Header.Set("Origin", "https://example.com")
+	preflight.Header.Set("Access-Control-Request-Method", "POST")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, preflight)
+
+	if response.Code != http.StatusNoContent || called {
+		t.Errorf("expected preflight to be answered by the middleware, got %d", response.Code)
+	}
+	if response.Header().Get("Access-Control-Allow-Origin") != "https://example.com" ||
+		!strings.Contains(response.Header().Get("Access-Control-Allow-Methods"), "POST") {
+		t.Errorf("unexpected preflight headers %v", response.Header())
+	}
+
+	other := httptest.NewRequest("GET", "/crocs", nil)
+	other.Header.Set("Origin", "https://evil.example")
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, other)
+	if !called || response.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected no CORS headers for unknown origin")
+	}
+}
+
+func TestCORSRejectsCredentialsWithAnyOrigin(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected CORS to panic for AllowCredentials with origin \"*\"")
+		}
+	}()
+	CORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
+
+func TestBodyLimit(t *testing.T) {
+	handler := BodyLimit(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	}))
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("POST", "/", strings.NewReader("this body is too long")))
+	if response.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 from Content-Length, got %d", response.Code)
+	}
+
+	// Unknown length: the limit is enforced while reading
+	request := httptest.NewRequest("POST", "/", io.NopCloser(strings.NewReader("this body is too long")))
+	request.ContentLength = -1
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 while reading, got %d", response.Code)
+	}
+}
@@ -0,0 +1,169 @@
+// Package router adds method routing, route groups and OPTIONS handling on
+// top of http.ServeMux. Patterns use the ServeMux syntax, so named
+// parameters such as /crocs/{id} are read with r.PathValue("id").
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/guilhermehermes/curso-go/mux/middleware"
+)
+
+// Router dispatches on method and path. Requests to a known path with an
+// unregistered method get 405 with an Allow header, and OPTIONS requests
+// are answered with the allowed methods unless a route handles OPTIONS.
+type Router struct {
+	routes
+
+	mux     *http.ServeMux
+	handler http.Handler
+
+	mu    sync.RWMutex
+	paths map[string]*path
+}
+
+// path holds the methods registered for one pattern
+type path struct {
+	methods []string
+	options http.Handler
+}
+
+// New returns an empty Router
+func New() *Router {
+	r := &Router{mux: http.NewServeMux(), paths: map[string]*path{}}
+	r.routes = routes{router: r}
+	r.handler = r.mux
+	return r
+}
+
+// Use adds middleware around the whole router, including 404 and 405
+// responses. Unlike Group.Use it applies to routes registered before it.
+func (r *Router) Use(middlewares ...middleware.Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handler = middleware.Chain(middlewares...)(r.handler)
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	handler := r.handler
+	r.mu.RUnlock()
+	handler.ServeHTTP(w, req)
+}
+
+func (r *Router) handle(method, pattern string, handler http.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.paths[pattern]
+	if !ok {
+		p = &path{}
+		r.paths[pattern] = p
+		// The router owns OPTIONS for every pattern, so an explicit OPTIONS
+		// route never conflicts with the automatic one
+		r.mux.Handle(http.MethodOptions+" "+pattern, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r.options(w, req, p)
+		}))
+	}
+
+	if method == http.MethodOptions {
+		p.options = handler
+		return
+	}
+	if slices.Contains(p.methods, method) {
+		panic("router: " + method + " " + pattern + " registered twice")
+	}
+	p.methods = append(p.methods, method)
+	r.mux.Handle(method+" "+pattern, handler)
+}
+
+func (r *Router) options(w http.ResponseWriter, req *http.Request, p *path) {
+	r.mu.RLock()
+	explicit := p.options
+	allow := allowed(p.methods)
+	r.mu.RUnlock()
+
+	if explicit != nil {
+		explicit.ServeHTTP(w, req)
+		return
+	}
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowed lists methods for an Allow header: HEAD comes with GET, as in
+// ServeMux, and OPTIONS is always available
+func allowed(methods []string) []string {
+	allow := slices.Clone(methods)
+	if slices.Contains(allow, http.MethodGet) && !slices.Contains(allow, http.MethodHead) {
+		allow = append(allow, http.MethodHead)
+	}
+	allow = append(allow, http.MethodOptions)
+	slices.Sort(allow)
+	return allow
+}
+
+// Group registers routes under a shared prefix and middleware. Middleware
+// added with Use applies to routes registered after the call.
+type Group struct {
+	routes
+}
+
+// routes holds the registration methods shared by Router and Group
+type routes struct {
+	router      *Router
+	prefix      string
+	middlewares []middleware.Middleware
+}
+
+// Group returns a sub-group whose routes start with prefix and run through
+// the parent's middleware followed by middlewares
+func (g *routes) Group(prefix string, middlewares ...middleware.Middleware) *Group {
+	return &Group{routes{
+		router:      g.router,
+		prefix:      g.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: append(slices.Clone(g.middlewares), middlewares...),
+	}}
+}
+
+// Use adds middleware to routes registered on g from now on
+func (g *routes) Use(middlewares ...middleware.Middleware) {
+	g.middlewares = append(g.middlewares, middlewares...)
+}
+
+// Handle registers handler for method and pattern, e.g. "GET", "/crocs/{id}".
+// In a group, an empty pattern matches the group prefix exactly.
+func (g *routes) Handle(method, pattern string, handler http.Handler) {
+	if !strings.HasPrefix(g.prefix+pattern, "/") {
+		panic("router: pattern must start with /: " + pattern)
+	}
+	g.router.handle(strings.ToUpper(method), g.prefix+pattern, middleware.Chain(g.middlewares...)(handler))
+}
+
+// HandleFunc is Handle for handler functions
+func (g *routes) HandleFunc(method, pattern string, handler http.HandlerFunc) {
+	g.Handle(method, pattern, handler)
+}
+
+func (g *routes) Get(pattern string, handler http.HandlerFunc) {
+	g.Handle(http.MethodGet, pattern, handler)
+}
+
+func (g *routes) Post(pattern string, handler http.HandlerFunc) {
+	g.Handle(http.MethodPost, pattern, handler)
+}
+
+func (g *routes) Put(pattern string, handler http.HandlerFunc) {
+	g.Handle(http.MethodPut, pattern, handler)
+}
+
+func (g *routes) Patch(pattern string, handler http.HandlerFunc) {
+	g.Handle(http.MethodPatch, pattern, handler)
+}
+
+func (g *routes) Delete(pattern string, handler http.HandlerFunc) {
+	g.Handle(http.MethodDelete, pattern, handler)
+}
@@ -0,0 +1,104 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guilhermehermes/curso-go/mux/middleware"
+)
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, httptest.NewRequest(method, target, nil))
+	return response
+}
+
+func TestRouting(t *testing.T) {
+	r := New()
+	r.Get("/crocs/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("get " + req.PathValue("id")))
+	})
+	r.Delete("/crocs/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("delete " + req.PathValue("id")))
+	})
+
+	if body := serve(r, "GET", "/crocs/7").Body.String(); body != "get 7" {
+		t.Errorf("GET: unexpected body %q", body)
+	}
+	if body := serve(r, "DELETE", "/crocs/7").Body.String(); body != "delete 7" {
+		t.Errorf("DELETE: unexpected body %q", body)
+	}
+	if code := serve(r, "GET", "/sandals/7").Code; code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", code)
+	}
+}
+
+func TestMethodNotAllowedAndOptions(t *testing.T) {
+	r := New()
+	r.Get("/crocs/{id}", func(w http.ResponseWriter, req *http.Request) {})
+	r.Put("/crocs/{id}", func(w http.ResponseWriter, req *http.Request) {})
+
+	response := serve(r, "POST", "/crocs/1")
+	if response.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", response.Code)
+	}
+	for _, method := range []string{"GET", "PUT", "OPTIONS"} {
+		if !strings.Contains(response.Header().Get("Allow"), method) {
+			t.Errorf("expected %s in Allow %q", method, response.Header().Get("Allow"))
+		}
+	}
+
+	response = serve(r, "OPTIONS", "/crocs/1")
+	if response.Code != http.StatusNoContent || response.Header().Get("Allow") != "GET, HEAD, OPTIONS, PUT" {
+		t.Errorf("unexpected OPTIONS response %d %q", response.Code, response.Header().Get("Allow"))
+	}
+
+	// An explicit OPTIONS route replaces the automatic one
+	r.HandleFunc("OPTIONS", "/crocs/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	if code := serve(r, "OPTIONS", "/crocs/1").Code; code != http.StatusTeapot {
+		t.Errorf("expected explicit OPTIONS handler, got %d", code)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	header := func(name string) middleware.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Chain", name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+
+	r := New()
+	r.Get("/health", func(w http.ResponseWriter, req *http.Request) {})
+	api := r.Group("/api/", header("api"))
+	v1 := api.Group("/v1", header("v1"))
+	v1.Get("", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("v1"))
+	})
+	v1.Get("/crocs/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.PathValue("id")))
+	})
+
+	response := serve(r, "GET", "/api/v1/crocs/3")
+	if response.Body.String() != "3" || strings.Join(response.Header().Values("X-Chain"), ",") != "api,v1" {
+		t.Errorf("unexpected group response %q %v", response.Body.String(), response.Header()["X-Chain"])
+	}
+	if body := serve(r, "GET", "/api/v1").Body.String(); body != "v1" {
+		t.Errorf("expected group root route, got %q", body)
+	}
+	if chain := serve(r, "GET", "/health").Header().Values("X-Chain"); len(chain) != 0 {
+		t.Errorf("group middleware leaked to root route: %v", chain)
+	}
+
+	// Router middleware also wraps 404s
+	r.Use(header("global"))
+	if chain := serve(r, "GET", "/missing").Header().Values("X-Chain"); len(chain) != 1 || chain[0] != "global" {
+		t.Errorf("expected global middleware on 404, got %v", chain)
+	}
+}
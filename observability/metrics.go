@@ -0,0 +1,93 @@
+package observability
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// latencyBuckets are the histogram upper bounds in seconds
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(latencyBuckets))}
+}
+
+func (h *histogram) observe(seconds float64) {
+	h.count++
+	h.sum += seconds
+	if i := sort.SearchFloat64s(latencyBuckets, seconds); i < len(latencyBuckets) {
+		h.counts[i]++
+	}
+}
+
+// Metrics serves the collected metrics in Prometheus text format
+func (o *Observer) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	o.WriteMetrics(w)
+}
+
+// WriteMetrics writes the collected metrics in Prometheus text format
+func (o *Observer) WriteMetrics(w io.Writer) error {
+	o.mu.Lock()
+	keys := make([]requestKey, 0, len(o.requests))
+	for key := range o.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP http_requests_total Requests served, by method, route and status.\n")
+	b.WriteString("# TYPE http_requests_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "http_requests_total{method=\"%s\",route=\"%s\",status=\"%d\"} %d\n",
+			escape(key.method), escape(key.route), key.status, o.requests[key])
+	}
+
+	b.WriteString("# HELP http_request_duration_seconds Request latency, by route.\n")
+	b.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, route := range sortedKeys(o.latency) {
+		h := o.latency[route]
+		label := escape(route)
+		cumulative := uint64(0)
+		for i, bound := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "http_request_duration_seconds_bucket{route=\"%s\",le=\"%s\"} %d\n",
+				label, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "http_request_duration_seconds_bucket{route=\"%s\",le=\"+Inf\"} %d\n", label, h.count)
+		fmt.Fprintf(&b, "http_request_duration_seconds_sum{route=\"%s\"} %g\n", label, h.sum)
+		fmt.Fprintf(&b, "http_request_duration_seconds_count{route=\"%s\"} %d\n", label, h.count)
+	}
+	o.mu.Unlock()
+
+	b.WriteString("# HELP http_requests_in_flight Requests currently being served.\n")
+	b.WriteString("# TYPE http_requests_in_flight gauge\n")
+	fmt.Fprintf(&b, "http_requests_in_flight %d\n", o.inFlight.Load())
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escape quotes a label value as the Prometheus text format expects
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
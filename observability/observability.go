@@ -0,0 +1,247 @@
+// Package observability gives every HTTP server the same probe and metrics
+// endpoints: /healthz (liveness), /readyz (readiness with pluggable checks)
+// and /metrics (Prometheus text format).
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check reports whether a dependency is usable; a nil error means ready
+type Check func(ctx context.Context) error
+
+// Observer collects request metrics and serves the probe endpoints
+type Observer struct {
+	// CheckTimeout bounds each readiness check (default 2s)
+	CheckTimeout time.Duration
+
+	started  time.Time
+	ready    atomic.Bool
+	inFlight atomic.Int64
+
+	mu       sync.Mutex
+	checks   map[string]Check
+	requests map[requestKey]uint64
+	latency  map[string]*histogram
+}
+
+type requestKey struct {
+	method string
+	route  string
+	status int
+}
+
+// New returns an Observer that reports ready until SetReady(false)
+func New() *Observer {
+	o := &Observer{
+		CheckTimeout: 2 * time.Second,
+		started:      time.Now(),
+		checks:       map[string]Check{},
+		requests:     map[requestKey]uint64{},
+		latency:      map[string]*histogram{},
+	}
+	o.ready.Store(true)
+	return o
+}
+
+// AddCheck registers a readiness check, e.g. a database ping
+func (o *Observer) AddCheck(name string, check Check) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.checks[name] = check
+}
+
+// SetReady switches readiness independently of the checks, e.g. to false
+// while the server drains connections before shutting down
+func (o *Observer) SetReady(ready bool) {
+	o.ready.Store(ready)
+}
+
+// Mux is satisfied by *http.ServeMux
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Mount registers /healthz, /readyz and /metrics on mux
+func (o *Observer) Mount(mux Mux) {
+	mux.Handle("/healthz", http.HandlerFunc(o.Healthz))
+	mux.Handle("/readyz", http.HandlerFunc(o.Readyz))
+	mux.Handle("/metrics", http.HandlerFunc(o.Metrics))
+}
+
+// Healthz answers 200 while the process is able to serve requests
+func (o *Observer) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "ok",
+		"uptime": time.Since(o.started).Round(time.Second).String(),
+	})
+}
+
+// readiness is the /readyz response body
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Readyz runs every check concurrently and answers 503 if any fails
+func (o *Observer) Readyz(w http.ResponseWriter, r *http.Request) {
+	if !o.ready.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, readiness{Status: "shutting down"})
+		return
+	}
+
+	o.mu.Lock()
+	checks := make(map[string]Check, len(o.checks))
+	for name, check := range o.checks {
+		checks[name] = check
+	}
+	o.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), o.CheckTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	body := readiness{Status: "ok", Checks: map[string]string{}}
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := "ok"
+			if err := check(ctx); err != nil {
+				result = "error: " + err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			body.Checks[name] = result
+			if result != "ok" {
+				body.Status = "unavailable"
+			}
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if body.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, body)
+}
+
+// PingCheck adapts anything with PingContext, such as *sql.DB
+func PingCheck(db interface{ PingContext(context.Context) error }) Check {
+	return db.PingContext
+}
+
+// HTTPCheck reports an upstream as unavailable when it cannot be reached
+// or answers with a 5xx status. client defaults to http.DefaultClient.
+func HTTPCheck(client *http.Client, url string) Check {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		request, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+		response.Body.Close()
+		if response.StatusCode >= 500 {
+			return fmt.Errorf("%s returned %d", url, response.StatusCode)
+		}
+		return nil
+	}
+}
+
+// Middleware records count, latency and in-flight requests. Requests are
+// labelled with the ServeMux pattern that matched them, so it must wrap
+// the ServeMux directly: a middleware in between that replaces the request
+// (e.g. with r.WithContext) hides the pattern and the route is reported as
+// "unmatched".
+func (o *Observer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o.inFlight.Add(1)
+		defer o.inFlight.Add(-1)
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		o.observe(requestKey{r.Method, route, recorder.status}, time.Since(start))
+	})
+}
+
+func (o *Observer) observe(key requestKey, duration time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.requests[key]++
+	h, ok := o.latency[key.route]
+	if !ok {
+		h = newHistogram()
+		o.latency[key.route] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+// statusRecorder remembers the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if !s.wroteHeader {
+		s.status = status
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+func (s *statusRecorder) Flush() {
+	s.wroteHeader = true
+	http.NewResponseController(s.ResponseWriter).Flush()
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// sortedKeys returns the keys of m in order, for stable metrics output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,110 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, httptest.NewRequest("GET", target, nil))
+	return response
+}
+
+func TestReadyz(t *testing.T) {
+	o := New()
+	mux := http.NewServeMux()
+	o.Mount(mux)
+
+	if code := get(mux, "/healthz").Code; code != http.StatusOK {
+		t.Errorf("healthz: expected 200, got %d", code)
+	}
+	if code := get(mux, "/readyz").Code; code != http.StatusOK {
+		t.Errorf("readyz without checks: expected 200, got %d", code)
+	}
+
+	o.AddCheck("db", func(ctx context.Context) error { return nil })
+	o.AddCheck("upstream", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	response := get(mux, "/readyz")
+	var body readiness
+	json.NewDecoder(response.Body).Decode(&body)
+	if response.Code != http.StatusServiceUnavailable || body.Checks["db"] != "ok" ||
+		body.Checks["upstream"] != "error: connection refused" {
+		t.Errorf("unexpected readiness %d %+v", response.Code, body)
+	}
+
+	// A slow check is cut off by CheckTimeout
+	o = New()
+	o.CheckTimeout = 20 * time.Millisecond
+	o.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if code := get(http.HandlerFunc(o.Readyz), "/readyz").Code; code != http.StatusServiceUnavailable {
+		t.Errorf("expected slow check to fail, got %d", code)
+	}
+
+	o = New()
+	o.SetReady(false)
+	if code := get(http.HandlerFunc(o.Readyz), "/readyz").Code; code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while not ready, got %d", code)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	o := New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /crocs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "0" {
+			http.NotFound(w, r)
+		}
+	})
+	handler := o.Middleware(mux)
+
+	get(handler, "/crocs/1")
+	get(handler, "/crocs/2")
+	get(handler, "/crocs/0")
+	get(handler, "/missing")
+
+	var b strings.Builder
+	o.WriteMetrics(&b)
+	metrics := b.String()
+
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="GET /crocs/{id}",status="200"} 2`,
+		`http_requests_total{method="GET",route="GET /crocs/{id}",status="404"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_bucket{route="GET /crocs/{id}",le="+Inf"} 3`,
+		`http_request_duration_seconds_count{route="GET /crocs/{id}"} 3`,
+		"# TYPE http_request_duration_seconds histogram",
+		"http_requests_in_flight 0",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("expected %q in metrics:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	status := http.StatusOK
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer upstream.Close()
+
+	check := HTTPCheck(nil, upstream.URL)
+	if err := check(context.Background()); err != nil {
+		t.Errorf("expected upstream to be ready, got %v", err)
+	}
+	status = http.StatusBadGateway
+	if err := check(context.Background()); err == nil {
+		t.Error("expected 5xx upstream to fail the check")
+	}
+}